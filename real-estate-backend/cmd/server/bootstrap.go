@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+
+	"real-estate-backend/internal/handlers"
+	"real-estate-backend/internal/models"
+)
+
+// bootstrapAdmin invites the first admin when none exists yet. It is a no-op once
+// an admin has accepted, and does not resend while an invitation is still pending.
+func bootstrapAdmin(email string, userRepo *models.UserRepository, invitationRepo *models.InvitationRepository, invitationHandler *handlers.InvitationHandler) {
+	if email == "" {
+		return
+	}
+
+	adminExists, err := userRepo.AdminExists()
+	if err != nil {
+		log.Printf("Bootstrap admin: failed to check for existing admin: %v", err)
+		return
+	}
+	if adminExists {
+		return
+	}
+
+	pending, err := invitationRepo.HasPending(email)
+	if err != nil {
+		log.Printf("Bootstrap admin: failed to check for pending invitation: %v", err)
+		return
+	}
+	if pending {
+		log.Printf("Bootstrap admin: invitation for %s is still pending", email)
+		return
+	}
+
+	if _, err := invitationHandler.IssueInvitation(email, models.UserTypeAdmin, nil); err != nil {
+		log.Printf("Bootstrap admin: failed to invite %s: %v", email, err)
+		return
+	}
+	log.Printf("Bootstrap admin: invitation sent to %s", email)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"real-estate-backend/internal/config"
 	"real-estate-backend/internal/database"
@@ -57,6 +58,7 @@ func main() {
 	// Run auto-migration
 	log.Println("Running database migrations...")
 	if err := database.AutoMigrate(
+		&models.Agency{},
 		&models.User{},
 		&models.County{},
 		&models.SubCounty{},
@@ -64,6 +66,16 @@ func main() {
 		&models.PropertyImage{},
 		&models.EmailVerification{},
 		&models.PasswordReset{},
+		&models.UserSession{},
+		&models.Invitation{},
+		&models.UserRole{},
+		&models.TwoFactorRecoveryCode{},
+		&models.TwoFactorPolicy{},
+		&models.UserVerification{},
+		&models.LoginAttempt{},
+		&models.AuditLog{},
+		&models.Review{},
+		&models.ReviewReport{},
 		// Add other models here as needed
 	); err != nil {
 		log.Fatal("Failed to run database migrations:", err)
@@ -71,7 +83,10 @@ func main() {
 	log.Println("Database migrations completed successfully")
 
 	// Initialize JWT manager
-	jwtManager := auth.NewJWTManager(&cfg.JWT)
+	jwtManager, err := auth.NewJWTManager(&cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to initialize JWT manager:", err)
+	}
 
 	// Initialize repositories
 	userRepo := models.NewUserRepository(database.GetDB())
@@ -81,9 +96,37 @@ func main() {
 	propertyImageRepo := models.NewPropertyImageRepository(database.GetDB())
 	emailVerificationRepo := models.NewEmailVerificationRepository(database.GetDB())
 	passwordResetRepo := models.NewPasswordResetRepository(database.GetDB())
+	sessionRepo := models.NewUserSessionRepository(database.GetDB())
+	invitationRepo := models.NewInvitationRepository(database.GetDB())
+	userRoleRepo := models.NewUserRoleRepository(database.GetDB())
+	twoFactorRepo := models.NewTwoFactorRepository(database.GetDB())
+	verificationRepo := models.NewUserVerificationRepository(database.GetDB())
+	loginAttemptRepo := models.NewLoginAttemptRepository(database.GetDB())
+	agencyRepo := models.NewAgencyRepository(database.GetDB())
+	statsRepo := models.NewStatsRepository(database.GetDB())
+	auditRepo := models.NewAuditLogRepository(database.GetDB())
+	reviewRepo := models.NewReviewRepository(database.GetDB())
 	// rentalApplicationRepo := models.NewRentalApplicationRepository(database.GetDB())
-	// leaseRepo := models.NewLeaseRepository(database.GetDB())
-	// paymentRepo := models.NewPaymentRepository(database.GetDB())
+
+	// Leases and payments are queried through database/sql
+	sqlDB, err := database.GetDB().DB()
+	if err != nil {
+		log.Fatal("Failed to get database connection:", err)
+	}
+	leaseRepo := models.NewLeaseRepository(sqlDB)
+	paymentRepo := models.NewPaymentRepository(sqlDB)
+
+	// Search relies on the search_vector trigger, which AutoMigrate does not create
+	if err := propertyRepo.CheckSearchTrigger(); err != nil {
+		log.Fatal("Property search is not set up:", err)
+	}
+
+	// Record the geohash of properties saved before map clustering existed
+	if count, err := propertyRepo.BackfillGeohashes(); err != nil {
+		log.Fatal("Failed to backfill property geohashes:", err)
+	} else if count > 0 {
+		log.Printf("Backfilled the geohash of %d properties", count)
+	}
 
 	// Initialize services
 	// mpesaService := services.NewMPesaService(&cfg.MPesa)
@@ -92,15 +135,43 @@ func main() {
 		log.Fatal("Failed to initialize Cloudinary service:", err)
 	}
 	emailService := services.NewEmailService(&cfg.Email)
+	smsSender, err := services.NewSMSSender(&cfg.SMS)
+	if err != nil {
+		log.Fatal("Failed to initialize SMS sender:", err)
+	}
+	attemptStore, err := services.NewAttemptStore(&cfg.Security, loginAttemptRepo)
+	if err != nil {
+		log.Fatal("Failed to initialize attempt store:", err)
+	}
+	loginThrottle := services.NewLoginThrottle(attemptStore)
+	userCache := services.NewUserCache(time.Duration(cfg.Server.UserCacheTTLSeconds) * time.Second)
+	userRepo.SetInvalidator(userCache.Invalidate)
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userRepo, jwtManager, emailVerificationRepo, emailService)
-	propertyHandler := handlers.NewPropertyHandler(propertyRepo, propertyImageRepo, cloudinaryService, &cfg.Upload)
+	userHandler := handlers.NewUserHandler(userRepo, jwtManager, emailVerificationRepo, sessionRepo, twoFactorRepo, loginThrottle, emailService, auditRepo)
+	propertyHandler := handlers.NewPropertyHandler(propertyRepo, propertyImageRepo, userRepo, cloudinaryService, &cfg.Upload, auditRepo, reviewRepo, cfg.Server.ListingEditsRequireReview)
 	locationHandler := handlers.NewLocationHandler(countyRepo, subCountyRepo)
-	emailVerificationHandler := handlers.NewEmailVerificationHandler(userRepo, emailVerificationRepo, emailService)
-	passwordResetHandler := handlers.NewPasswordResetHandler(userRepo, passwordResetRepo, emailService)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(userRepo, emailVerificationRepo, loginThrottle, emailService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(userRepo, passwordResetRepo, loginThrottle, emailService, auditRepo)
+	jwksHandler := handlers.NewJWKSHandler(jwtManager)
+	invitationHandler := handlers.NewInvitationHandler(userRepo, invitationRepo, emailService, auditRepo)
+	roleHandler := handlers.NewRoleHandler(userRepo, userRoleRepo, twoFactorRepo, auditRepo)
+	phoneVerificationHandler := handlers.NewPhoneVerificationHandler(userRepo, verificationRepo, smsSender)
+	lockoutHandler := handlers.NewLockoutHandler(loginThrottle, auditRepo)
+	kycHandler := handlers.NewKYCHandler(verificationRepo, userRepo, cloudinaryService, &cfg.Upload, auditRepo)
+	agencyHandler := handlers.NewAgencyHandler(agencyRepo, userRepo, propertyRepo, cloudinaryService, &cfg.Upload, auditRepo)
+	profileHandler := handlers.NewProfileHandler(userRepo, cloudinaryService, &cfg.Upload)
+	adminUserHandler := handlers.NewAdminUserHandler(userRepo, propertyRepo, verificationRepo, auditRepo)
+	adminStatsHandler := handlers.NewAdminStatsHandler(statsRepo, time.Duration(cfg.Server.StatsCacheSeconds)*time.Second)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	ownerHandler := handlers.NewOwnerHandler(propertyRepo, leaseRepo, paymentRepo)
+	reviewHandler := handlers.NewReviewHandler(reviewRepo, propertyRepo, userRepo, auditRepo)
+	amenityHandler := handlers.NewAmenityHandler()
 	// paymentHandler := handlers.NewPaymentHandler(paymentRepo, leaseRepo, mpesaService)
 
+	// Invite the first admin if none exists yet
+	bootstrapAdmin(cfg.Server.BootstrapAdminEmail, userRepo, invitationRepo, invitationHandler)
+
 	// Set up Gin router
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -124,11 +195,16 @@ func main() {
 		})
 	})
 
+	// Public keys for verifying access tokens (not under /api/v1)
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Web forms for password reset (not under /api/v1)
 	web := router.Group("/web")
 	{
 		web.GET("/reset-password", passwordResetHandler.GetResetPasswordForm)
 		web.POST("/reset-password", passwordResetHandler.PostResetPasswordForm)
+		web.GET("/accept-invitation", invitationHandler.GetAcceptInvitationForm)
+		web.POST("/accept-invitation", invitationHandler.PostAcceptInvitationForm)
 	}
 
 	// API routes
@@ -140,10 +216,20 @@ func main() {
 		// User authentication
 		public.POST("/register", userHandler.Register)
 		public.POST("/login", userHandler.Login)
+		public.POST("/auth/refresh", userHandler.RefreshToken)
+		public.POST("/auth/logout", userHandler.Logout)
+		public.POST("/auth/2fa/verify", userHandler.VerifyTwoFactorLogin)
 
 		// Public property listings
 		public.GET("/properties", propertyHandler.GetPublicProperties)
+		public.GET("/properties/clusters", propertyHandler.GetPropertyClusters)
+		public.GET("/amenities", amenityHandler.GetAmenities)
 		public.GET("/properties/:id", propertyHandler.GetProperty)
+		public.GET("/properties/:id/reviews", reviewHandler.GetPropertyReviews)
+
+		// Agent profiles and reviews (public)
+		public.GET("/agents/:agentId", reviewHandler.GetAgentProfile)
+		public.GET("/agents/:agentId/reviews", reviewHandler.GetAgentReviews)
 
 		// Location data
 		public.GET("/counties", locationHandler.GetCounties)
@@ -160,6 +246,10 @@ func main() {
 		public.POST("/auth/reset-password", passwordResetHandler.ResetPassword)
 		public.GET("/auth/validate-reset-token", passwordResetHandler.ValidateResetToken)
 
+		// Admin and staff invitations (public)
+		public.GET("/invitations/validate", invitationHandler.ValidateInvitation)
+		public.POST("/invitations/accept", invitationHandler.AcceptInvitation)
+
 		// M-Pesa callback (public endpoint for Safaricom)
 		// public.POST("/payments/mpesa/callback", paymentHandler.HandleMPesaCallback)
 	}
@@ -167,52 +257,151 @@ func main() {
 	// Protected routes (authentication required)
 	protected := api.Group("/")
 	protected.Use(middleware.AuthMiddleware(jwtManager))
+	protected.Use(middleware.LoadUser(userRepo, userCache))
 	{
 		// User profile
 		protected.GET("/profile", userHandler.GetProfile)
+		protected.PUT("/profile", profileHandler.UpdateProfile)
+		protected.POST("/profile/photo", profileHandler.UploadProfilePhoto)
+		protected.DELETE("/profile/photo", profileHandler.DeleteProfilePhoto)
 
 		// Email verification (protected)
 		protected.POST("/send-verification-email", emailVerificationHandler.SendVerificationEmail)
 		protected.GET("/verification-status", emailVerificationHandler.GetVerificationStatus)
 
+		// Phone verification (protected)
+		protected.POST("/send-phone-verification", phoneVerificationHandler.SendPhoneVerification)
+		protected.POST("/verify-phone", phoneVerificationHandler.VerifyPhone)
+
+		// KYC documents (protected)
+		protected.POST("/kyc/documents", kycHandler.UploadDocument)
+		protected.GET("/kyc/documents", kycHandler.GetMyDocuments)
+
 		// Password management (protected)
 		protected.POST("/auth/change-password", passwordResetHandler.ChangePassword)
+		protected.POST("/auth/change-email", emailVerificationHandler.RequestEmailChange)
+
+		// Session management (protected)
+		protected.POST("/auth/logout-all", userHandler.LogoutAll)
+		protected.GET("/auth/sessions", userHandler.GetSessions)
+		protected.DELETE("/auth/sessions/:id", userHandler.RevokeSession)
+
+		// Two-factor authentication (protected)
+		protected.GET("/auth/2fa", userHandler.GetTwoFactorStatus)
+		protected.POST("/auth/2fa/setup", userHandler.SetupTwoFactor)
+		protected.POST("/auth/2fa/enable", userHandler.EnableTwoFactor)
+		protected.POST("/auth/2fa/disable", userHandler.DisableTwoFactor)
+		protected.POST("/auth/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+
+		// Roles and permissions of the authenticated user
+		protected.GET("/auth/permissions", roleHandler.GetMyPermissions)
+
+		// Review replies (agent of the review only) and reports
+		protected.POST("/reviews/:id/reply", reviewHandler.ReplyToReview)
+		protected.POST("/reviews/:id/report", reviewHandler.ReportReview)
 
-		// Admin routes - admin access required
+		// Admin routes - each route declares the permission it requires
 		adminRoutes := protected.Group("/admin")
-		adminRoutes.Use(middleware.RequireUserType("admin"))
+		adminRoutes.Use(middleware.RequireTwoFactor(twoFactorRepo))
+		{
+			adminRoutes.GET("/pending-agents", middleware.RequirePermission(models.PermAgentRead), userHandler.GetPendingAgents)
+			adminRoutes.POST("/approve-agent/:agentId", middleware.RequirePermission(models.PermAgentApprove), userHandler.ApproveAgent)
+			adminRoutes.GET("/agents", middleware.RequirePermission(models.PermAgentRead), userHandler.GetAllAgents)
+			adminRoutes.POST("/agents/:agentId/reject", middleware.RequirePermission(models.PermAgentApprove), userHandler.RejectAgent)
+			adminRoutes.POST("/agents/:agentId/suspend", middleware.RequirePermission(models.PermAgentApprove), userHandler.SuspendAgent)
+			adminRoutes.POST("/agents/:agentId/revoke", middleware.RequirePermission(models.PermAgentApprove), userHandler.RevokeAgentApproval)
+			adminRoutes.POST("/agents/:agentId/reinstate", middleware.RequirePermission(models.PermAgentApprove), userHandler.ReinstateAgent)
+			adminRoutes.GET("/kyc/documents", middleware.RequirePermission(models.PermAgentApprove), kycHandler.GetPendingDocuments)
+			adminRoutes.POST("/kyc/documents/:id/review", middleware.RequirePermission(models.PermAgentApprove), kycHandler.ReviewDocument)
+			adminRoutes.POST("/agencies", middleware.RequirePermission(models.PermAgencyAdmin), agencyHandler.CreateAgency)
+			adminRoutes.GET("/agencies", middleware.RequirePermission(models.PermAgencyAdmin), agencyHandler.GetAgencies)
+			adminRoutes.POST("/agencies/:id/members", middleware.RequirePermission(models.PermAgencyAdmin), agencyHandler.AddMember)
+			adminRoutes.DELETE("/agencies/:id/members/:userId", middleware.RequirePermission(models.PermAgencyAdmin), agencyHandler.RemoveMember)
+			adminRoutes.GET("/stats", middleware.RequirePermission(models.PermStatsRead), adminStatsHandler.GetStats)
+			adminRoutes.GET("/audit-logs", middleware.RequirePermission(models.PermAuditRead), auditHandler.GetAuditLogs)
+			adminRoutes.GET("/audit-logs/export", middleware.RequirePermission(models.PermAuditRead), auditHandler.ExportAuditLogs)
+			adminRoutes.GET("/audit-logs/verify", middleware.RequirePermission(models.PermAuditRead), auditHandler.VerifyAuditLogs)
+			adminRoutes.GET("/users", middleware.RequirePermission(models.PermUserRead), adminUserHandler.GetUsers)
+			adminRoutes.GET("/users/:userId", middleware.RequirePermission(models.PermUserRead), adminUserHandler.GetUser)
+			adminRoutes.POST("/users/:userId/deactivate", middleware.RequirePermission(models.PermUserManage), adminUserHandler.DeactivateUser)
+			adminRoutes.POST("/users/:userId/reactivate", middleware.RequirePermission(models.PermUserManage), adminUserHandler.ReactivateUser)
+			adminRoutes.POST("/users/:userId/revoke-sessions", middleware.RequirePermission(models.PermSessionRevoke), userHandler.RevokeUserSessions)
+			adminRoutes.POST("/invitations", middleware.RequirePermission(models.PermUserInvite), invitationHandler.CreateInvitation)
+			adminRoutes.GET("/invitations", middleware.RequirePermission(models.PermUserInvite), invitationHandler.GetPendingInvitations)
+			adminRoutes.DELETE("/invitations/:id", middleware.RequirePermission(models.PermUserInvite), invitationHandler.RevokeInvitation)
+			adminRoutes.GET("/roles", middleware.RequirePermission(models.PermRoleAssign), roleHandler.GetRoles)
+			adminRoutes.GET("/users/:userId/roles", middleware.RequirePermission(models.PermRoleAssign), roleHandler.GetUserRoles)
+			adminRoutes.POST("/users/:userId/roles", middleware.RequirePermission(models.PermRoleAssign), roleHandler.AssignRole)
+			adminRoutes.DELETE("/users/:userId/roles/:role", middleware.RequirePermission(models.PermRoleAssign), roleHandler.RemoveRole)
+			adminRoutes.GET("/2fa-policies", middleware.RequirePermission(models.PermSecurityManage), roleHandler.GetTwoFactorPolicies)
+			adminRoutes.PUT("/2fa-policies", middleware.RequirePermission(models.PermSecurityManage), roleHandler.SetTwoFactorPolicy)
+			adminRoutes.GET("/lockouts", middleware.RequirePermission(models.PermSecurityManage), lockoutHandler.GetLockouts)
+			adminRoutes.POST("/lockouts/clear", middleware.RequirePermission(models.PermSecurityManage), lockoutHandler.ClearLockout)
+			adminRoutes.GET("/reviews/reported", middleware.RequirePermission(models.PermReviewModerate), reviewHandler.GetReportedReviews)
+			adminRoutes.POST("/reviews/:id/moderate", middleware.RequirePermission(models.PermReviewModerate), reviewHandler.ModerateReview)
+			adminRoutes.GET("/properties/pending", middleware.RequirePermission(models.PermPropertyModerate), propertyHandler.GetPendingProperties)
+			adminRoutes.POST("/properties/:id/approve", middleware.RequirePermission(models.PermPropertyModerate), propertyHandler.ApproveProperty)
+			adminRoutes.POST("/properties/:id/reject", middleware.RequirePermission(models.PermPropertyModerate), propertyHandler.RejectProperty)
+		}
+
+		// Property management - requires email verification, and admin approval for agents.
+		// Handlers additionally check ownership unless the user holds property:write:any.
+		propertyRoutes := protected.Group("/")
+		propertyRoutes.Use(middleware.RequireTwoFactor(twoFactorRepo))
+		propertyRoutes.Use(middleware.RequireVerifiedEmail())
+		propertyRoutes.Use(middleware.RequireApprovedAgent())
+		{
+			propertyRoutes.POST("/properties", middleware.RequirePermission(models.PermPropertyWriteOwn), propertyHandler.CreateProperty)
+			propertyRoutes.PUT("/properties/:id", middleware.RequireAnyPermission(models.PermPropertyWriteOwn, models.PermPropertyWriteAny, models.PermAgencyManage), propertyHandler.UpdateProperty)
+			propertyRoutes.DELETE("/properties/:id", middleware.RequireAnyPermission(models.PermPropertyWriteOwn, models.PermPropertyWriteAny, models.PermAgencyManage), propertyHandler.DeleteProperty)
+			propertyRoutes.POST("/properties/:id/status", middleware.RequireAnyPermission(models.PermPropertyWriteOwn, models.PermPropertyWriteAny, models.PermAgencyManage), propertyHandler.UpdatePropertyStatus)
+			propertyRoutes.GET("/my-properties", middleware.RequirePermission(models.PermPropertyWriteOwn), propertyHandler.GetMyProperties)
+			propertyRoutes.POST("/properties/:id/images", middleware.RequireAnyPermission(models.PermPropertyWriteOwn, models.PermPropertyWriteAny, models.PermAgencyManage), propertyHandler.AddPropertyImage)
+			propertyRoutes.DELETE("/properties/:id/images/:image_id", middleware.RequireAnyPermission(models.PermPropertyWriteOwn, models.PermPropertyWriteAny, models.PermAgencyManage), propertyHandler.DeletePropertyImage)
+		}
+
+		// Agency management - managers see and edit every listing of their agency
+		agencyRoutes := protected.Group("/agency")
+		agencyRoutes.Use(middleware.RequireTwoFactor(twoFactorRepo))
+		agencyRoutes.Use(middleware.RequirePermission(models.PermAgencyManage))
 		{
-			adminRoutes.GET("/pending-agents", userHandler.GetPendingAgents)
-			adminRoutes.POST("/approve-agent/:agentId", userHandler.ApproveAgent)
-			adminRoutes.GET("/agents", userHandler.GetAllAgents)
+			agencyRoutes.GET("", agencyHandler.GetMyAgency)
+			agencyRoutes.PUT("", agencyHandler.UpdateMyAgency)
+			agencyRoutes.POST("/logo", agencyHandler.UploadLogo)
+			agencyRoutes.GET("/properties", agencyHandler.GetAgencyProperties)
+			agencyRoutes.POST("/properties/:id/reassign", agencyHandler.ReassignProperty)
 		}
 
-		// Property management (agent only) - requires email verification and admin approval
-		agentRoutes := protected.Group("/")
-		agentRoutes.Use(middleware.RequireUserType("agent"))
-		agentRoutes.Use(middleware.RequireVerifiedEmail(userRepo))
-		agentRoutes.Use(middleware.RequireApprovedAgent(userRepo))
+		// Landlord routes - read-only access to owned units; agents manage them
+		ownerRoutes := protected.Group("/owner")
+		ownerRoutes.Use(middleware.RequireTwoFactor(twoFactorRepo))
+		ownerRoutes.Use(middleware.RequirePermission(models.PermOwnerRead))
 		{
-			agentRoutes.POST("/properties", propertyHandler.CreateProperty)
-			agentRoutes.PUT("/properties/:id", propertyHandler.UpdateProperty)
-			agentRoutes.DELETE("/properties/:id", propertyHandler.DeleteProperty)
-			agentRoutes.GET("/my-properties", propertyHandler.GetMyProperties)
-			agentRoutes.POST("/properties/:id/images", propertyHandler.AddPropertyImage)
-			agentRoutes.DELETE("/properties/:id/images/:image_id", propertyHandler.DeletePropertyImage)
+			ownerRoutes.GET("/properties", ownerHandler.GetMyUnits)
+			ownerRoutes.GET("/leases", ownerHandler.GetMyLeases)
+			ownerRoutes.GET("/payments", ownerHandler.GetMyPayments)
+			ownerRoutes.GET("/statement", ownerHandler.GetMyStatement)
 		}
 
 		// Tenant routes - requires email verification for applications and payments
 		tenantRoutes := protected.Group("/")
-		tenantRoutes.Use(middleware.RequireUserType("tenant"))
-		tenantRoutes.Use(middleware.RequireVerifiedEmail(userRepo))
+		tenantRoutes.Use(middleware.RequirePermission(models.PermRentalApply))
+		tenantRoutes.Use(middleware.RequireTwoFactor(twoFactorRepo))
+		tenantRoutes.Use(middleware.RequireVerifiedEmail())
 		{
 			// tenantRoutes.POST("/applications", applicationHandler.CreateApplication)
 			// tenantRoutes.GET("/my-applications", applicationHandler.GetMyApplications)
 			// tenantRoutes.GET("/my-leases", leaseHandler.GetMyLeases)
+			tenantRoutes.POST("/properties/:id/reviews", middleware.RequirePermission(models.PermReviewWrite), reviewHandler.CreatePropertyReview)
+			tenantRoutes.POST("/agents/:agentId/reviews", middleware.RequirePermission(models.PermReviewWrite), reviewHandler.CreateAgentReview)
+		}
 
-			// Payment routes for tenants
-			// tenantRoutes.POST("/payments/initiate", paymentHandler.InitiateRentPayment)
-			// tenantRoutes.GET("/payments/status/:checkout_request_id", paymentHandler.QueryPaymentStatus)
+		// Payment routes for tenants - M-Pesa payments also require a verified phone number
+		paymentRoutes := tenantRoutes.Group("/payments")
+		paymentRoutes.Use(middleware.RequireVerifiedPhone())
+		{
+			// paymentRoutes.POST("/initiate", paymentHandler.InitiateRentPayment)
+			// paymentRoutes.GET("/status/:checkout_request_id", paymentHandler.QueryPaymentStatus)
 		}
 
 		// Payment routes accessible by both landlords and tenants
@@ -224,6 +413,9 @@ func main() {
 		// protected.GET("/favorites", favoriteHandler.GetFavorites)
 	}
 
+	// Every refresh token rotation writes a session, so prune expired ones hourly
+	go pruneExpiredSessions(sessionRepo, time.Hour)
+
 	// Start server
 	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("Starting server on %s", serverAddr)
@@ -231,3 +423,17 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// pruneExpiredSessions deletes expired refresh sessions now and then at every interval
+func pruneExpiredSessions(sessionRepo *models.UserSessionRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if count, err := sessionRepo.DeleteExpired(); err != nil {
+			log.Printf("Failed to delete expired sessions: %v", err)
+		} else if count > 0 {
+			log.Printf("Deleted %d expired sessions", count)
+		}
+		<-ticker.C
+	}
+}
@@ -46,7 +46,7 @@ gcloud run deploy $SERVICE_NAME \
   --max-instances 10 \
   --min-instances 0 \
   --port 8080 \
-  --set-env-vars="APP_ENV=production,SERVER_HOST=0.0.0.0,SERVER_PORT=8080,DB_HOST=${DB_HOST:-localhost},DB_PORT=${DB_PORT:-5432},DB_USER=${DB_USER:-postgres},DB_PASSWORD=${DB_PASSWORD},DB_NAME=${DB_NAME:-kenyan_real_estate},DB_SSL_MODE=${DB_SSL_MODE:-require},JWT_SECRET=${JWT_SECRET:-change-this-in-production},JWT_EXPIRY_HOURS=${JWT_EXPIRY_HOURS:-24},CLOUDINARY_CLOUD_NAME=${CLOUDINARY_CLOUD_NAME},CLOUDINARY_API_KEY=${CLOUDINARY_API_KEY},CLOUDINARY_API_SECRET=${CLOUDINARY_API_SECRET},CLOUDINARY_FOLDER=${CLOUDINARY_FOLDER:-real-estate-properties},EMAIL_HOST=${EMAIL_HOST:-smtp.gmail.com},EMAIL_PORT=${EMAIL_PORT:-587},EMAIL_USERNAME=${EMAIL_USERNAME},EMAIL_PASSWORD=${EMAIL_PASSWORD},EMAIL_FROM=${EMAIL_FROM:-noreply@kenyanrealestate.com},EMAIL_SUPPORT=${EMAIL_SUPPORT:-support@kenyanrealestate.com},BASE_URL=${BASE_URL:-https://kenyanrealestate.com},MPESA_CONSUMER_KEY=${MPESA_CONSUMER_KEY},MPESA_CONSUMER_SECRET=${MPESA_CONSUMER_SECRET},MPESA_ENVIRONMENT=${MPESA_ENVIRONMENT:-sandbox},MPESA_PASS_KEY=${MPESA_PASS_KEY},MPESA_SHORT_CODE=${MPESA_SHORT_CODE}"
+  --set-env-vars="APP_ENV=production,BOOTSTRAP_ADMIN_EMAIL=${BOOTSTRAP_ADMIN_EMAIL},SERVER_HOST=0.0.0.0,SERVER_PORT=8080,DB_HOST=${DB_HOST:-localhost},DB_PORT=${DB_PORT:-5432},DB_USER=${DB_USER:-postgres},DB_PASSWORD=${DB_PASSWORD},DB_NAME=${DB_NAME:-kenyan_real_estate},DB_SSL_MODE=${DB_SSL_MODE:-require},JWT_SECRET=${JWT_SECRET},JWT_ACCESS_TOKEN_MINUTES=${JWT_ACCESS_TOKEN_MINUTES:-15},JWT_REFRESH_TOKEN_DAYS=${JWT_REFRESH_TOKEN_DAYS:-30},CLOUDINARY_CLOUD_NAME=${CLOUDINARY_CLOUD_NAME},CLOUDINARY_API_KEY=${CLOUDINARY_API_KEY},CLOUDINARY_API_SECRET=${CLOUDINARY_API_SECRET},CLOUDINARY_FOLDER=${CLOUDINARY_FOLDER:-real-estate-properties},EMAIL_HOST=${EMAIL_HOST:-smtp.gmail.com},EMAIL_PORT=${EMAIL_PORT:-587},EMAIL_USERNAME=${EMAIL_USERNAME},EMAIL_PASSWORD=${EMAIL_PASSWORD},EMAIL_FROM=${EMAIL_FROM:-noreply@kenyanrealestate.com},EMAIL_SUPPORT=${EMAIL_SUPPORT:-support@kenyanrealestate.com},BASE_URL=${BASE_URL:-https://kenyanrealestate.com},MPESA_CONSUMER_KEY=${MPESA_CONSUMER_KEY},MPESA_CONSUMER_SECRET=${MPESA_CONSUMER_SECRET},MPESA_ENVIRONMENT=${MPESA_ENVIRONMENT:-sandbox},MPESA_PASS_KEY=${MPESA_PASS_KEY},MPESA_SHORT_CODE=${MPESA_SHORT_CODE},ATTEMPT_STORE=${ATTEMPT_STORE:-database}"
 
 # Get the service URL
 SERVICE_URL=$(gcloud run services describe $SERVICE_NAME --platform managed --region $REGION --format 'value(status.url)')
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"os"
 	"strconv"
 )
@@ -15,13 +18,20 @@ type Config struct {
 	Cloudinary CloudinaryConfig
 	MPesa     MPesaConfig
 	Email     EmailConfig
+	SMS       SMSConfig
+	Security  SecurityConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Host string
-	Port int
-	Env  string
+	Host                string
+	Port                int
+	Env                 string
+	BootstrapAdminEmail string // Invited as the first admin when no admin exists
+	UserCacheTTLSeconds int    // How long a loaded user is reused across requests; 0 disables the cache
+	StatsCacheSeconds   int    // How long computed admin dashboard statistics are reused; 0 disables the cache
+	// Send published listings back to the moderation queue when their key fields or photos change
+	ListingEditsRequireReview bool
 }
 
 // DatabaseConfig holds database connection configuration
@@ -37,8 +47,12 @@ type DatabaseConfig struct {
 
 // JWTConfig holds JWT-related configuration
 type JWTConfig struct {
-	Secret     string
-	ExpiryHours int
+	Algorithm          string // HS256, RS256 or EdDSA
+	Secret             string // HS256 only
+	KeysDir            string // RS256/EdDSA: directory of <kid>.pem files
+	ActiveKeyID        string // RS256/EdDSA: kid of the key new tokens are signed with
+	AccessTokenMinutes int
+	RefreshTokenDays   int
 }
 
 // UploadConfig holds file upload configuration
@@ -76,13 +90,30 @@ type EmailConfig struct {
 	BaseURL      string
 }
 
+// SMSConfig holds SMS delivery configuration
+type SMSConfig struct {
+	Provider    string // log or memory; neither is allowed in production
+	SenderID    string
+	Environment string
+}
+
+// SecurityConfig holds brute-force protection configuration
+type SecurityConfig struct {
+	AttemptStore string // memory or database
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	config := &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnvAsInt("SERVER_PORT", 8080),
-			Env:  getEnv("APP_ENV", "development"),
+			Host:                getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:                getEnvAsInt("SERVER_PORT", 8080),
+			Env:                 getEnv("APP_ENV", "development"),
+			BootstrapAdminEmail: getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
+			UserCacheTTLSeconds: getEnvAsInt("USER_CACHE_TTL_SECONDS", 30),
+			StatsCacheSeconds:   getEnvAsInt("STATS_CACHE_TTL_SECONDS", 60),
+
+			ListingEditsRequireReview: getEnvAsBool("LISTING_EDITS_REQUIRE_REVIEW", false),
 		},
 		Database: DatabaseConfig{
 			Host:        getEnv("DB_HOST", "localhost"),
@@ -94,8 +125,12 @@ func Load() (*Config, error) {
 			Environment: getEnv("APP_ENV", "development"),
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			ExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+			Algorithm:          getEnv("JWT_ALGORITHM", "HS256"),
+			Secret:             getEnv("JWT_SECRET", ""),
+			KeysDir:            getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID:        getEnv("JWT_ACTIVE_KEY_ID", ""),
+			AccessTokenMinutes: getEnvAsInt("JWT_ACCESS_TOKEN_MINUTES", 15),
+			RefreshTokenDays:   getEnvAsInt("JWT_REFRESH_TOKEN_DAYS", 30),
 		},
 		Upload: UploadConfig{
 			MaxFileSize:  getEnvAsInt64("MAX_FILE_SIZE", 10*1024*1024), // 10MB
@@ -124,11 +159,42 @@ func Load() (*Config, error) {
 			SupportEmail: getEnv("EMAIL_SUPPORT", "support@kenyanrealestate.com"),
 			BaseURL:      getEnv("BASE_URL", "http://localhost:3000"),
 		},
+		SMS: SMSConfig{
+			Provider:    getEnv("SMS_PROVIDER", "log"),
+			SenderID:    getEnv("SMS_SENDER_ID", "RealEstate"),
+			Environment: getEnv("APP_ENV", "development"),
+		},
+		Security: SecurityConfig{
+			AttemptStore: getEnv("ATTEMPT_STORE", "memory"),
+		},
+	}
+
+	if err := config.JWT.ensureSecret(config.Server.Env); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// ensureSecret refuses to start without a JWT secret in production. Outside
+// production a random secret is generated so tokens never use a guessable default.
+func (c *JWTConfig) ensureSecret(env string) error {
+	if c.Algorithm != "HS256" || c.Secret != "" {
+		return nil
+	}
+	if env == "production" {
+		return fmt.Errorf("JWT_SECRET is required when JWT_ALGORITHM is HS256")
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+	c.Secret = hex.EncodeToString(bytes)
+	log.Println("Warning: JWT_SECRET not set. Using a random secret; tokens will not survive a restart.")
+	return nil
+}
+
 // GetDSN returns the database connection string
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -161,3 +227,11 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatsRange is the longest date range the statistics can cover
+const maxStatsRange = 2 * 366 * 24 * time.Hour
+
+// AdminStatsHandler serves the admin dashboard statistics
+type AdminStatsHandler struct {
+	statsRepo *models.StatsRepository
+	cacheTTL  time.Duration
+	mu        sync.Mutex
+	cache     map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	stats     *models.AdminStats
+	expiresAt time.Time
+}
+
+// NewAdminStatsHandler creates a new admin stats handler. Computed statistics
+// are reused for cacheTTL so the dashboard can poll cheaply; a zero TTL disables
+// the cache.
+func NewAdminStatsHandler(statsRepo *models.StatsRepository, cacheTTL time.Duration) *AdminStatsHandler {
+	return &AdminStatsHandler{
+		statsRepo: statsRepo,
+		cacheTTL:  cacheTTL,
+		cache:     make(map[string]statsCacheEntry),
+	}
+}
+
+// GetStats returns dashboard statistics (requires stats:read)
+// @Summary Get dashboard statistics
+// @Description Get user counts by type and state, signups over time, active listings per county and property type with average and median rent, and listings added or removed per week. Results are cached for STATS_CACHE_TTL_SECONDS (default 60).
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days before to"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD), default today"
+// @Param interval query string false "Signup grouping" Enums(day,week,month) default(day)
+// @Success 200 {object} models.AdminStats "Statistics"
+// @Failure 400 {object} object{error=string} "Invalid date range or interval"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/stats [get]
+func (h *AdminStatsHandler) GetStats(c *gin.Context) {
+	interval := c.DefaultQuery("interval", "day")
+	if !models.StatsIntervals[interval] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid interval, expected day, week or month",
+		})
+		return
+	}
+
+	from, to, ok := bindDateRange(c)
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxStatsRange {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date range, the range must not exceed two years",
+		})
+		return
+	}
+
+	key := from.Format("2006-01-02") + "/" + to.Format("2006-01-02") + "/" + interval
+	if stats, ok := h.cached(key); ok {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, err := h.statsRepo.GetAdminStats(from, to, interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute statistics",
+		})
+		return
+	}
+	h.store(key, stats)
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// cached returns statistics computed for the key within the cache TTL
+func (h *AdminStatsHandler) cached(key string) (*models.AdminStats, bool) {
+	if h.cacheTTL <= 0 {
+		return nil, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+// store caches statistics for the key, dropping expired entries
+func (h *AdminStatsHandler) store(key string, stats *models.AdminStats) {
+	if h.cacheTTL <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range h.cache {
+		if now.After(entry.expiresAt) {
+			delete(h.cache, k)
+		}
+	}
+	h.cache[key] = statsCacheEntry{stats: stats, expiresAt: now.Add(h.cacheTTL)}
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AdminUserHandler handles the admin user directory
+type AdminUserHandler struct {
+	userRepo         *models.UserRepository
+	propertyRepo     *models.PropertyRepository
+	verificationRepo *models.UserVerificationRepository
+	auditRepo        *models.AuditLogRepository
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler(userRepo *models.UserRepository, propertyRepo *models.PropertyRepository, verificationRepo *models.UserVerificationRepository, auditRepo *models.AuditLogRepository) *AdminUserHandler {
+	return &AdminUserHandler{
+		userRepo:         userRepo,
+		propertyRepo:     propertyRepo,
+		verificationRepo: verificationRepo,
+		auditRepo:        auditRepo,
+	}
+}
+
+// GetUsers lists users with search, filters and pagination (requires user:read)
+// @Summary Search users
+// @Description Search all users, including deactivated accounts, by name, email or phone number
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param q query string false "Search name, email or phone number"
+// @Param user_type query string false "User type" Enums(agent,landlord,tenant,admin,staff)
+// @Param is_verified query bool false "Email verified"
+// @Param is_approved query bool false "Agent approved"
+// @Param is_active query bool false "Account active"
+// @Param registered_from query string false "Registered on or after (YYYY-MM-DD)"
+// @Param registered_to query string false "Registered on or before (YYYY-MM-DD)"
+// @Param sort_by query string false "Sort field" Enums(created_at,name,email,user_type) default(created_at)
+// @Param sort_order query string false "Sort order" Enums(asc,desc) default(desc)
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{users=[]models.AdminUserResponse,total=int,limit=int,offset=int} "Users"
+// @Failure 400 {object} object{error=string} "Invalid filter"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users [get]
+func (h *AdminUserHandler) GetUsers(c *gin.Context) {
+	filters := &models.UserSearchFilters{
+		Query:    strings.TrimSpace(c.Query("q")),
+		SortBy:   c.DefaultQuery("sort_by", "created_at"),
+		SortDesc: c.DefaultQuery("sort_order", "desc") == "desc",
+	}
+
+	if _, ok := models.UserSortColumns[filters.SortBy]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid sort_by, expected one of created_at, name, email, user_type",
+		})
+		return
+	}
+
+	if userTypeStr := c.Query("user_type"); userTypeStr != "" {
+		userType := models.UserType(userTypeStr)
+		filters.UserType = &userType
+	}
+
+	for name, target := range map[string]**bool{
+		"is_verified": &filters.IsVerified,
+		"is_approved": &filters.IsApproved,
+		"is_active":   &filters.IsActive,
+	} {
+		if valueStr := c.Query(name); valueStr != "" {
+			value, err := strconv.ParseBool(valueStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid " + name + ", expected true or false",
+				})
+				return
+			}
+			*target = &value
+		}
+	}
+
+	if fromStr := c.Query("registered_from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid registered_from, expected YYYY-MM-DD",
+			})
+			return
+		}
+		filters.RegisteredFrom = &from
+	}
+	if toStr := c.Query("registered_to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid registered_to, expected YYYY-MM-DD",
+			})
+			return
+		}
+		// Include the whole day
+		to = to.AddDate(0, 0, 1)
+		filters.RegisteredTo = &to
+	}
+
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if filters.Limit <= 0 || filters.Limit > 100 {
+		filters.Limit = 20
+	}
+	filters.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if filters.Offset < 0 {
+		filters.Offset = 0
+	}
+
+	users, total, err := h.userRepo.Search(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search users",
+		})
+		return
+	}
+
+	responses := make([]*models.AdminUserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToAdminResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  responses,
+		"total":  total,
+		"limit":  filters.Limit,
+		"offset": filters.Offset,
+	})
+}
+
+// GetUser returns a user with their properties and verification records (requires user:read)
+// @Summary Get user details
+// @Description Get a user, including deactivated accounts, with a page of the properties they manage, newest first, and their verification records
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID"
+// @Param limit query int false "Number of properties per page" default(20)
+// @Param offset query int false "Number of properties to skip" default(0)
+// @Success 200 {object} object{user=models.AdminUserResponse,properties=[]models.Property,properties_total=int,limit=int,offset=int,verifications=[]models.VerificationResponse} "User details"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId} [get]
+func (h *AdminUserHandler) GetUser(c *gin.Context) {
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	properties, err := h.propertyRepo.GetByAgentID(user.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get properties",
+		})
+		return
+	}
+	propertiesTotal, err := h.propertyRepo.CountByAgentID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get properties",
+		})
+		return
+	}
+
+	verifications, err := h.verificationRepo.GetUserVerifications(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get verifications",
+		})
+		return
+	}
+	verificationResponses := make([]*models.VerificationResponse, len(verifications))
+	for i, verification := range verifications {
+		verificationResponses[i] = verification.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":             user.ToAdminResponse(),
+		"properties":       properties,
+		"properties_total": propertiesTotal,
+		"limit":            limit,
+		"offset":           offset,
+		"verifications":    verificationResponses,
+	})
+}
+
+// DeactivateUser deactivates an account (requires user:manage)
+// @Summary Deactivate a user
+// @Description Deactivate an account. The user is logged out everywhere and cannot log in until reactivated.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID"
+// @Success 200 {object} object{message=string,user=models.AdminUserResponse} "User deactivated"
+// @Failure 400 {object} object{error=string} "Invalid user ID or own account"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/deactivate [post]
+func (h *AdminUserHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// ReactivateUser reactivates a deactivated account (requires user:manage)
+// @Summary Reactivate a user
+// @Description Reactivate a deactivated account so the user can log in again
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID"
+// @Success 200 {object} object{message=string,user=models.AdminUserResponse} "User reactivated"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/reactivate [post]
+func (h *AdminUserHandler) ReactivateUser(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// setActive deactivates or reactivates the user named in the URL
+func (h *AdminUserHandler) setActive(c *gin.Context, active bool) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	// An admin locking themselves out could leave nobody to undo it
+	if adminID, ok := getUserID(c); ok && adminID == userID && !active {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You cannot deactivate your own account",
+		})
+		return
+	}
+
+	if err := h.userRepo.SetActive(userID, active); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update user",
+		})
+		return
+	}
+
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	status, action := "reactivated", models.AuditUserReactivate
+	if !active {
+		status, action = "deactivated", models.AuditUserDeactivate
+	}
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+	}, map[string]interface{}{"is_active": !active}, map[string]interface{}{"is_active": active})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User " + status + " successfully",
+		"user":    user.ToAdminResponse(),
+	})
+}
+
+// loadUser loads the user named in the URL, active or not, writing an error
+// response if it cannot
+func (h *AdminUserHandler) loadUser(c *gin.Context) (*models.User, bool) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByIDIncludingInactive(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,587 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"real-estate-backend/internal/config"
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AgencyHandler handles agencies, their members and their shared portfolio
+type AgencyHandler struct {
+	agencyRepo        *models.AgencyRepository
+	userRepo          *models.UserRepository
+	propertyRepo      *models.PropertyRepository
+	cloudinaryService *services.CloudinaryService
+	uploadConfig      *config.UploadConfig
+	auditRepo         *models.AuditLogRepository
+}
+
+// NewAgencyHandler creates a new agency handler
+func NewAgencyHandler(
+	agencyRepo *models.AgencyRepository,
+	userRepo *models.UserRepository,
+	propertyRepo *models.PropertyRepository,
+	cloudinaryService *services.CloudinaryService,
+	uploadConfig *config.UploadConfig,
+	auditRepo *models.AuditLogRepository,
+) *AgencyHandler {
+	return &AgencyHandler{
+		agencyRepo:        agencyRepo,
+		userRepo:          userRepo,
+		propertyRepo:      propertyRepo,
+		cloudinaryService: cloudinaryService,
+		uploadConfig:      uploadConfig,
+		auditRepo:         auditRepo,
+	}
+}
+
+// CreateAgency creates an agency (requires agency:admin)
+// @Summary Create an agency
+// @Description Create an estate agency. Agents are added to it separately.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.CreateAgencyRequest true "Agency details"
+// @Success 201 {object} object{message=string,agency=models.Agency} "Agency created"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 409 {object} object{error=string} "Licence number already registered"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agencies [post]
+func (h *AgencyHandler) CreateAgency(c *gin.Context) {
+	adminID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	var req models.CreateAgencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	exists, err := h.agencyRepo.LicenceExists(req.LicenceNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check licence number",
+		})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An agency with this licence number already exists",
+		})
+		return
+	}
+
+	agency := &models.Agency{
+		Name:          req.Name,
+		Email:         req.Email,
+		PhoneNumber:   req.PhoneNumber,
+		Website:       req.Website,
+		Address:       req.Address,
+		LicenceNumber: req.LicenceNumber,
+		CreatedBy:     &adminID,
+	}
+	if err := h.agencyRepo.Create(agency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create agency",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditAgencyCreate,
+		TargetType: models.AuditTargetAgency,
+		TargetID:   agency.ID.String(),
+	}, nil, models.AuditSnapshot(agency))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Agency created successfully",
+		"agency":  agency,
+	})
+}
+
+// GetAgencies lists all agencies (requires agency:admin)
+// @Summary List agencies
+// @Description Get all agencies ordered by name
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{agencies=[]models.Agency} "Agencies"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agencies [get]
+func (h *AgencyHandler) GetAgencies(c *gin.Context) {
+	agencies, err := h.agencyRepo.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agencies",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"agencies": agencies,
+	})
+}
+
+// AddMember adds an agent to an agency (requires agency:admin)
+// @Summary Add an agent to an agency
+// @Description Make an agent a member of the agency. The agent's listings that have no agency join the agency's portfolio. To make the agent a manager, also assign them the agency_manager role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Agency ID"
+// @Param request body models.AgencyMemberRequest true "Agent to add"
+// @Success 200 {object} object{message=string} "Agent added"
+// @Failure 400 {object} object{error=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agency or agent not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agencies/{id}/members [post]
+func (h *AgencyHandler) AddMember(c *gin.Context) {
+	agency, ok := h.getAgencyFromParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AgencyMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.userRepo.JoinAgency(req.UserID, agency.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agent not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add agent to agency",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditAgencyMemberAdd,
+		TargetType: models.AuditTargetAgency,
+		TargetID:   agency.ID.String(),
+		Changes:    models.AuditChanges{"member": {After: req.UserID}},
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agent added to agency successfully",
+	})
+}
+
+// RemoveMember removes a user from an agency (requires agency:admin)
+// @Summary Remove a member from an agency
+// @Description Remove a user from the agency. Their listings stay in the agency's portfolio so a manager can reassign them.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Agency ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} object{message=string} "Member removed"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agency not found or user is not a member"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agencies/{id}/members/{userId} [delete]
+func (h *AgencyHandler) RemoveMember(c *gin.Context) {
+	agency, ok := h.getAgencyFromParam(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.userRepo.LeaveAgency(userID, agency.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User is not a member of this agency",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove member",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditAgencyMemberRemove,
+		TargetType: models.AuditTargetAgency,
+		TargetID:   agency.ID.String(),
+		Changes:    models.AuditChanges{"member": {Before: userID}},
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed from agency successfully",
+	})
+}
+
+// GetMyAgency returns the manager's agency and its members (requires agency:manage)
+// @Summary Get my agency
+// @Description Get the agency the authenticated manager belongs to, with its members
+// @Tags Agency
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{agency=models.Agency,members=[]models.UserResponse} "Agency"
+// @Failure 403 {object} object{error=string} "Not a member of an agency"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agency [get]
+func (h *AgencyHandler) GetMyAgency(c *gin.Context) {
+	agency, ok := h.getManagedAgency(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.agencyRepo.GetMembers(agency.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agency members",
+		})
+		return
+	}
+
+	memberResponses := make([]models.UserResponse, len(members))
+	for i, member := range members {
+		memberResponses[i] = *member.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"agency":  agency,
+		"members": memberResponses,
+	})
+}
+
+// UpdateMyAgency updates the manager's agency details (requires agency:manage)
+// @Summary Update my agency
+// @Description Update the agency's name and contact details. The licence number can only be changed by an admin.
+// @Tags Agency
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.UpdateAgencyRequest true "Agency details"
+// @Success 200 {object} object{message=string,agency=models.Agency} "Agency updated"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Not a member of an agency"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agency [put]
+func (h *AgencyHandler) UpdateMyAgency(c *gin.Context) {
+	agency, ok := h.getManagedAgency(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateAgencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Name != nil {
+		agency.Name = *req.Name
+	}
+	if req.Email != nil {
+		agency.Email = *req.Email
+	}
+	if req.PhoneNumber != nil {
+		agency.PhoneNumber = *req.PhoneNumber
+	}
+	if req.Website != nil {
+		agency.Website = req.Website
+	}
+	if req.Address != nil {
+		agency.Address = req.Address
+	}
+
+	if err := h.agencyRepo.Update(agency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update agency",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agency updated successfully",
+		"agency":  agency,
+	})
+}
+
+// UploadLogo replaces the manager's agency logo (requires agency:manage)
+// @Summary Upload agency logo
+// @Description Upload the logo shown on the agency's public listings
+// @Tags Agency
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param logo formData file true "Logo image"
+// @Success 200 {object} object{message=string,agency=models.Agency} "Logo uploaded"
+// @Failure 400 {object} object{error=string} "Invalid file"
+// @Failure 403 {object} object{error=string} "Not a member of an agency"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agency/logo [post]
+func (h *AgencyHandler) UploadLogo(c *gin.Context) {
+	agency, ok := h.getManagedAgency(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Logo file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if err := h.cloudinaryService.ValidateImageFile(header, h.uploadConfig.AllowedTypes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	uploadResponse, err := h.cloudinaryService.UploadAgencyLogo(c.Request.Context(), file, agency.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload logo",
+		})
+		return
+	}
+
+	oldPublicID := agency.LogoPublicID
+	agency.LogoURL = &uploadResponse.SecureURL
+	agency.LogoPublicID = &uploadResponse.PublicID
+	if err := h.agencyRepo.Update(agency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update agency",
+		})
+		return
+	}
+
+	if oldPublicID != nil {
+		if err := h.cloudinaryService.DeleteImage(c.Request.Context(), *oldPublicID); err != nil {
+			log.Printf("Failed to delete old logo %s for agency %s: %v", *oldPublicID, agency.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logo uploaded successfully",
+		"agency":  agency,
+	})
+}
+
+// GetAgencyProperties lists the agency's portfolio (requires agency:manage)
+// @Summary Get agency properties
+// @Description Get every listing in the manager's agency, whichever agent it is assigned to
+// @Tags Agency
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{properties=[]models.Property} "Agency properties"
+// @Failure 403 {object} object{error=string} "Not a member of an agency"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agency/properties [get]
+func (h *AgencyHandler) GetAgencyProperties(c *gin.Context) {
+	agency, ok := h.getManagedAgency(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	properties, err := h.propertyRepo.GetByAgencyID(agency.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agency properties",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"properties": properties,
+	})
+}
+
+// ReassignProperty moves a listing to another agent of the agency (requires agency:manage)
+// @Summary Reassign a property
+// @Description Assign one of the agency's listings to another agent of the agency. The new agent must be approved and not suspended.
+// @Tags Agency
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Property ID" Format(uuid)
+// @Param request body models.ReassignPropertyRequest true "New agent"
+// @Success 200 {object} object{message=string,property=models.Property} "Property reassigned"
+// @Failure 400 {object} object{error=string} "Invalid request data or agent cannot manage properties"
+// @Failure 403 {object} object{error=string} "Not a member of an agency"
+// @Failure 404 {object} object{error=string} "Property or agent not found in the agency"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agency/properties/{id}/reassign [post]
+func (h *AgencyHandler) ReassignProperty(c *gin.Context) {
+	agency, ok := h.getManagedAgency(c)
+	if !ok {
+		return
+	}
+
+	propertyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid property ID",
+		})
+		return
+	}
+
+	var req models.ReassignPropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	property, err := h.propertyRepo.GetByID(propertyID)
+	if err != nil || property.AgencyID == nil || *property.AgencyID != agency.ID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Property not found in your agency",
+		})
+		return
+	}
+
+	agent, err := h.userRepo.GetByID(req.AgentID)
+	if err != nil || agent.AgencyID == nil || *agent.AgencyID != agency.ID || agent.UserType != models.UserTypeAgent {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Agent not found in your agency",
+		})
+		return
+	}
+	if !agent.IsActive || !agent.CanManageProperties() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Agent is not approved to manage properties",
+		})
+		return
+	}
+
+	previousAgentID := property.AgentID
+	if err := h.propertyRepo.Reassign(property.ID, agency.ID, agent.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Property not found in your agency",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reassign property",
+		})
+		return
+	}
+	property.AgentID = agent.ID
+	property.Agent = agent
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyReassign,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+		Changes:    models.AuditChanges{"agent_id": {Before: previousAgentID, After: agent.ID}},
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Property reassigned successfully",
+		"property": property,
+	})
+}
+
+// getAgencyFromParam loads the agency named in the URL, writing an error response if it cannot
+func (h *AgencyHandler) getAgencyFromParam(c *gin.Context) (*models.Agency, bool) {
+	agencyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid agency ID",
+		})
+		return nil, false
+	}
+
+	agency, err := h.agencyRepo.GetByID(agencyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agency not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agency",
+		})
+		return nil, false
+	}
+	return agency, true
+}
+
+// getManagedAgency loads the authenticated user's agency, writing an error response if they have none
+func (h *AgencyHandler) getManagedAgency(c *gin.Context) (*models.Agency, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	if user.AgencyID == nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You are not a member of an agency",
+		})
+		return nil, false
+	}
+
+	agency, err := h.agencyRepo.GetByID(*user.AgencyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agency",
+		})
+		return nil, false
+	}
+	return agency, true
+}
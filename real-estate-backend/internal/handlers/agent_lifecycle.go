@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AgentActionRequest represents the reason an admin gives for rejecting, suspending
+// or revoking an agent. The reason is included in the email to the agent.
+type AgentActionRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// RejectAgent handles rejecting a pending agent application (requires agent:approve)
+// @Summary Reject an agent
+// @Description Reject a pending agent application. The agent is emailed the reason.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param agentId path string true "Agent ID"
+// @Param request body handlers.AgentActionRequest true "Reason for the rejection"
+// @Success 200 {object} object{message=string,agent=models.AdminUserResponse} "Agent rejected"
+// @Failure 400 {object} object{error=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string} "Agent is not pending"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agents/{agentId}/reject [post]
+func (h *UserHandler) RejectAgent(c *gin.Context) {
+	var req AgentActionRequest
+	if !bindAgentAction(c, &req) {
+		return
+	}
+	h.changeAgentStatus(c, "rejected", models.AuditAgentReject, req.Reason, func(agent *models.User, adminID uuid.UUID) error {
+		return agent.RejectAgent(adminID, req.Reason)
+	})
+}
+
+// SuspendAgent handles temporarily blocking an approved agent (requires agent:approve)
+// @Summary Suspend an agent
+// @Description Suspend an approved agent. The agent is logged out everywhere, cannot manage properties, and their listings are hidden from public search until they are reinstated.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param agentId path string true "Agent ID"
+// @Param request body handlers.AgentActionRequest true "Reason for the suspension"
+// @Success 200 {object} object{message=string,agent=models.AdminUserResponse} "Agent suspended"
+// @Failure 400 {object} object{error=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string} "Agent is not approved or already suspended"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agents/{agentId}/suspend [post]
+func (h *UserHandler) SuspendAgent(c *gin.Context) {
+	var req AgentActionRequest
+	if !bindAgentAction(c, &req) {
+		return
+	}
+	h.changeAgentStatus(c, "suspended", models.AuditAgentSuspend, req.Reason, func(agent *models.User, adminID uuid.UUID) error {
+		return agent.SuspendAgent(adminID, req.Reason)
+	})
+}
+
+// RevokeAgentApproval handles withdrawing an agent's approval (requires agent:approve)
+// @Summary Revoke an agent's approval
+// @Description Revoke an approved agent's approval. The agent is logged out everywhere and cannot manage properties until reinstated or approved again.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param agentId path string true "Agent ID"
+// @Param request body handlers.AgentActionRequest true "Reason for the revocation"
+// @Success 200 {object} object{message=string,agent=models.AdminUserResponse} "Approval revoked"
+// @Failure 400 {object} object{error=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string} "Agent is not approved"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agents/{agentId}/revoke [post]
+func (h *UserHandler) RevokeAgentApproval(c *gin.Context) {
+	var req AgentActionRequest
+	if !bindAgentAction(c, &req) {
+		return
+	}
+	h.changeAgentStatus(c, "revoked", models.AuditAgentRevoke, req.Reason, func(agent *models.User, adminID uuid.UUID) error {
+		return agent.RevokeAgentApproval(adminID, req.Reason)
+	})
+}
+
+// ReinstateAgent handles lifting a suspension or revocation (requires agent:approve)
+// @Summary Reinstate an agent
+// @Description Lift an agent's suspension and restore a revoked approval. Restoring approval requires the agent's national ID, KRA PIN certificate and estate agent licence to be verified.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param agentId path string true "Agent ID"
+// @Success 200 {object} object{message=string,agent=models.AdminUserResponse} "Agent reinstated"
+// @Failure 400 {object} object{error=string} "Invalid agent ID"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string,details=string} "Agent is neither suspended nor revoked, or required documents not verified"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/agents/{agentId}/reinstate [post]
+func (h *UserHandler) ReinstateAgent(c *gin.Context) {
+	h.changeAgentStatus(c, "reinstated", models.AuditAgentReinstate, "", func(agent *models.User, adminID uuid.UUID) error {
+		// Restoring approval passes the same document check as approving
+		if agent.IsRevoked() {
+			if err := h.userRepo.CheckKYCComplete(agent); err != nil {
+				return err
+			}
+		}
+		return agent.ReinstateAgent(adminID)
+	})
+}
+
+// bindAgentAction binds the reason for an agent action, writing an error response if it is missing
+func bindAgentAction(c *gin.Context, req *AgentActionRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// changeAgentStatus applies a lifecycle action to the agent named in the URL,
+// records the acting admin in the agent and the audit log, and emails the agent
+func (h *UserHandler) changeAgentStatus(c *gin.Context, status string, auditAction models.AuditAction, reason string, action func(agent *models.User, adminID uuid.UUID) error) {
+	adminID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	agentID, err := uuid.Parse(c.Param("agentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid agent ID",
+		})
+		return
+	}
+
+	var before map[string]interface{}
+	agent, err := h.userRepo.UpdateAgent(agentID, func(agent *models.User) error {
+		before = models.AuditSnapshot(agent.ToResponse())
+		return action(agent, adminID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agent not found",
+			})
+		case errors.Is(err, models.ErrKYCIncomplete):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Agent's required documents have not all been verified",
+				"details": err.Error(),
+			})
+		case errors.Is(err, models.ErrInvalidAgentTransition):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update agent",
+			})
+		}
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     auditAction,
+		TargetType: models.AuditTargetUser,
+		TargetID:   agent.ID.String(),
+	}, before, models.AuditSnapshot(agent.ToResponse()))
+
+	fullName := agent.FirstName + " " + agent.LastName
+	if err := h.emailService.SendAgentStatusEmail(agent.Email, fullName, status, reason); err != nil {
+		log.Printf("Failed to send agent %s email to %s: %v", status, agent.Email, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agent " + status + " successfully",
+		"agent":   agent.ToAdminResponse(),
+	})
+}
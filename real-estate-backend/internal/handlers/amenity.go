@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AmenityHandler serves the amenity catalog
+type AmenityHandler struct{}
+
+// NewAmenityHandler creates a new amenity handler
+func NewAmenityHandler() *AmenityHandler {
+	return &AmenityHandler{}
+}
+
+// GetAmenities handles getting the amenity catalog
+// @Summary Get amenities
+// @Description Get the amenities agents pick from when listing a property, grouped by category, with English and Swahili labels. Property amenities and the amenities search filter use the keys.
+// @Tags Properties
+// @Produce json
+// @Param category query string false "Only this category" Enums(security,utilities,kitchen,interior,recreation,building)
+// @Success 200 {object} object{amenities=map[string][]models.Amenity} "Amenities by category"
+// @Router /amenities [get]
+func (h *AmenityHandler) GetAmenities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"amenities": models.AmenitiesByCategory(models.AmenityCategory(c.Query("category"))),
+	})
+}
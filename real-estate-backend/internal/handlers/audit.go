@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// propertyAuditOmit lists the preloaded relationships left out of property snapshots
+var propertyAuditOmit = []string{"county", "sub_county", "agent", "agency", "images"}
+
+// recordAudit appends an entry to the audit log with the request's IP address
+// and user agent. The actor defaults to the authenticated user. Before and after
+// are snapshots from models.AuditSnapshot; the entry records the fields that
+// differ. The action has already happened, so a failure is logged rather than
+// returned to the client.
+func recordAudit(c *gin.Context, auditRepo *models.AuditLogRepository, entry *models.AuditLog, before, after map[string]interface{}) {
+	if entry.ActorID == nil {
+		if actorID, ok := getUserID(c); ok {
+			entry.ActorID = &actorID
+		}
+	}
+	entry.IPAddress = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	if entry.Changes == nil {
+		entry.Changes = models.AuditDiff(before, after)
+	}
+
+	if err := auditRepo.Append(entry); err != nil {
+		log.Printf("Failed to record audit entry %s on %s %s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// AuditHandler serves the audit log to admins
+type AuditHandler struct {
+	auditRepo *models.AuditLogRepository
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditRepo *models.AuditLogRepository) *AuditHandler {
+	return &AuditHandler{auditRepo: auditRepo}
+}
+
+// GetAuditLogs searches the audit log (requires audit:read)
+// @Summary Search the audit log
+// @Description Get audit entries, newest first, filtered by actor, action, target and date
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param actor_id query string false "User who performed the action"
+// @Param action query string false "Action, e.g. agent.approve or property.update"
+// @Param target_type query string false "Target type, e.g. user or property"
+// @Param target_id query string false "Target ID"
+// @Param from query string false "Created on or after (YYYY-MM-DD)"
+// @Param to query string false "Created on or before (YYYY-MM-DD)"
+// @Param limit query int false "Number of results per page" default(50)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{entries=[]models.AuditLog,total=int,limit=int,offset=int} "Audit entries"
+// @Failure 400 {object} object{error=string} "Invalid filter"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/audit-logs [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	filters := &models.AuditLogFilters{
+		Action:     models.AuditAction(c.Query("action")),
+		TargetType: models.AuditTargetType(c.Query("target_type")),
+		TargetID:   c.Query("target_id"),
+	}
+
+	if actorStr := c.Query("actor_id"); actorStr != "" {
+		actorID, err := uuid.Parse(actorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid actor_id",
+			})
+			return
+		}
+		filters.ActorID = &actorID
+	}
+
+	var ok bool
+	if filters.From, filters.To, ok = parseDateRange(c); !ok {
+		return
+	}
+
+	filters.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if filters.Limit <= 0 || filters.Limit > 200 {
+		filters.Limit = 50
+	}
+	filters.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if filters.Offset < 0 {
+		filters.Offset = 0
+	}
+
+	entries, total, err := h.auditRepo.Search(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"limit":   filters.Limit,
+		"offset":  filters.Offset,
+	})
+}
+
+// ExportAuditLogs streams the audit log as newline-delimited JSON (requires audit:read)
+// @Summary Export the audit log
+// @Description Download every entry in the date range, oldest first, one JSON object per line. Entries are contiguous and include sequence, prev_hash and hash, so the chain can be verified independently.
+// @Tags Admin
+// @Produce application/x-ndjson
+// @Security Bearer
+// @Param from query string false "Created on or after (YYYY-MM-DD)"
+// @Param to query string false "Created on or before (YYYY-MM-DD)"
+// @Success 200 {string} string "Audit entries"
+// @Failure 400 {object} object{error=string} "Invalid date range"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Router /admin/audit-logs/export [get]
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=audit-log-"+time.Now().UTC().Format("20060102T150405Z")+".ndjson")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	err := h.auditRepo.Each(from, to, func(entry *models.AuditLog) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		// The status line is already sent; a truncated file fails verification
+		log.Printf("Audit log export failed: %v", err)
+	}
+}
+
+// VerifyAuditLogs checks the audit log's hash chain (requires audit:read)
+// @Summary Verify the audit log
+// @Description Recompute the hash chain from the first entry. broken_at is the sequence of the first entry that was altered, removed or inserted.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{valid=bool,checked=int,broken_at=int} "Verification result"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/audit-logs/verify [get]
+func (h *AuditHandler) VerifyAuditLogs(c *gin.Context) {
+	checked, brokenAt, err := h.auditRepo.Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify audit log",
+		})
+		return
+	}
+
+	response := gin.H{
+		"valid":   brokenAt == nil,
+		"checked": checked,
+	}
+	if brokenAt != nil {
+		response["broken_at"] = *brokenAt
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDateRangeDays is how far back a date range reaches when from is not given
+const defaultDateRangeDays = 30
+
+// parseDateRange parses the optional from and to query dates, writing an error
+// response if either is invalid. Dates are whole days in UTC; to is inclusive,
+// so the range ends the next midnight.
+func parseDateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var from, to *time.Time
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+			return nil, nil, false
+		}
+		from = &parsed
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to date, expected YYYY-MM-DD",
+			})
+			return nil, nil, false
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		to = &parsed
+	}
+	return from, to, true
+}
+
+// bindDateRange parses the from and to query dates like parseDateRange, defaulting
+// to the last 30 days, and rejects a range where from is not before to.
+func bindDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	fromDate, toDate, ok := parseDateRange(c)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if toDate != nil {
+		to = *toDate
+	}
+	from := to.AddDate(0, 0, -defaultDateRangeDays)
+	if fromDate != nil {
+		from = *fromDate
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date range, from must not be after to",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -2,21 +2,25 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"html"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"real-estate-backend/internal/models"
 	"real-estate-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // EmailVerificationHandler handles email verification HTTP requests
 type EmailVerificationHandler struct {
 	userRepo                *models.UserRepository
 	emailVerificationRepo   *models.EmailVerificationRepository
+	loginThrottle           *services.LoginThrottle
 	emailService            *services.EmailService
 }
 
@@ -24,11 +28,13 @@ type EmailVerificationHandler struct {
 func NewEmailVerificationHandler(
 	userRepo *models.UserRepository,
 	emailVerificationRepo *models.EmailVerificationRepository,
+	loginThrottle *services.LoginThrottle,
 	emailService *services.EmailService,
 ) *EmailVerificationHandler {
 	return &EmailVerificationHandler{
 		userRepo:              userRepo,
 		emailVerificationRepo: emailVerificationRepo,
+		loginThrottle:         loginThrottle,
 		emailService:          emailService,
 	}
 }
@@ -47,36 +53,11 @@ func NewEmailVerificationHandler(
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /send-verification-email [post]
 func (h *EmailVerificationHandler) SendVerificationEmail(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User ID not found in context",
-		})
-		return
-	}
-
-	userUUID, ok := userID.(uuid.UUID)
+	user, ok := currentUser(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user ID format",
-		})
-		return
-	}
-
-	// Get user
-	user, err := h.userRepo.GetByID(userUUID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "User not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user",
-		})
 		return
 	}
+	userUUID := user.ID
 
 	// Check if user is already verified
 	if user.IsVerified {
@@ -210,6 +191,22 @@ func (h *EmailVerificationHandler) generateErrorHTML(title, message string) stri
 	`, title, title, message)
 }
 
+// markUsed marks the verification as used, writing an error page if it fails or
+// a concurrent request used it first
+func (h *EmailVerificationHandler) markUsed(c *gin.Context, verification *models.EmailVerification) bool {
+	if err := h.emailVerificationRepo.MarkAsUsed(verification.ID); err != nil {
+		if errors.Is(err, models.ErrEmailVerificationUsed) {
+			errorHTML := h.generateErrorHTML("Token Already Used", "This verification token has already been used. Your email may already be verified.")
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(errorHTML))
+			return false
+		}
+		errorHTML := h.generateErrorHTML("Server Error", "An error occurred while processing your verification. Please try again later.")
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(errorHTML))
+		return false
+	}
+	return true
+}
+
 // verifyEmailWithToken handles the common verification logic
 func (h *EmailVerificationHandler) verifyEmailWithToken(c *gin.Context, token string) {
 	// Get verification record by token
@@ -239,10 +236,14 @@ func (h *EmailVerificationHandler) verifyEmailWithToken(c *gin.Context, token st
 		return
 	}
 
+	// A link sent to a new address switches the account over to it
+	if verification.IsEmailChange() {
+		h.confirmEmailChange(c, verification)
+		return
+	}
+
 	// Mark verification as used
-	if err := h.emailVerificationRepo.MarkAsUsed(verification.ID); err != nil {
-		errorHTML := h.generateErrorHTML("Server Error", "An error occurred while processing your verification. Please try again later.")
-		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(errorHTML))
+	if !h.markUsed(c, verification) {
 		return
 	}
 
@@ -300,35 +301,16 @@ successHTML := fmt.Sprintf(`
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Success 200 {object} object{is_verified=bool,pending_verification=bool,can_resend=bool} "Verification status"
+// @Success 200 {object} object{is_verified=bool,pending_verification=bool,can_resend=bool,pending_email=string} "Verification status"
 // @Failure 401 {object} object{error=string} "Unauthorized"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /verification-status [get]
 func (h *EmailVerificationHandler) GetVerificationStatus(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User ID not found in context",
-		})
-		return
-	}
-
-	userUUID, ok := userID.(uuid.UUID)
+	user, ok := currentUser(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user ID format",
-		})
-		return
-	}
-
-	// Get user
-	user, err := h.userRepo.GetByID(userUUID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user",
-		})
 		return
 	}
+	userUUID := user.ID
 
 	response := gin.H{
 		"is_verified": user.IsVerified,
@@ -351,5 +333,208 @@ func (h *EmailVerificationHandler) GetVerificationStatus(c *gin.Context) {
 		}
 	}
 
+	// An email change waiting for confirmation
+	if pending, err := h.emailVerificationRepo.GetPendingEmailChange(userUUID); err == nil && !pending.IsExpired() {
+		response["pending_email"] = *pending.NewEmail
+	}
+
 	c.JSON(http.StatusOK, response)
 }
+
+// RequestEmailChange starts moving the account to a new email address
+// @Summary Change email address
+// @Description Send a confirmation link to a new email address. The account keeps its current address until the link is clicked; the old address is then notified of the change.
+// @Tags Email Verification
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.ChangeEmailRequest true "New email and current password"
+// @Success 200 {object} object{message=string} "Confirmation email sent"
+// @Failure 400 {object} object{error=string} "Invalid request data or incorrect password"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 409 {object} object{error=string} "Email already exists"
+// @Failure 429 {object} object{error=string} "Too many requests or too many wrong passwords"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/change-email [post]
+func (h *EmailVerificationHandler) RequestEmailChange(c *gin.Context) {
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Wrong passwords count as failed logins, so a stolen token cannot be used to guess the password
+	accountKey := services.AccountAttemptKey(user.Email)
+	ipKey := services.IPAttemptKey(c.ClientIP())
+	wait, err := h.loginThrottle.Check(accountKey, ipKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check login attempts",
+		})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
+	if !user.CheckPassword(req.CurrentPassword) {
+		for _, key := range []string{ipKey, accountKey} {
+			if _, _, err := h.loginThrottle.RecordFailure(key); err != nil {
+				log.Printf("Failed to record password failure for %s: %v", key, err)
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Current password is incorrect",
+		})
+		return
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "New email is the same as the current email",
+		})
+		return
+	}
+
+	exists, err := h.userRepo.EmailExists(newEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check email",
+		})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Email already exists",
+		})
+		return
+	}
+
+	// Same limit as verification emails: one every 5 minutes
+	pending, err := h.emailVerificationRepo.GetPendingEmailChange(user.ID)
+	if err == nil {
+		timeSinceLastEmail := time.Since(pending.CreatedAt)
+		if timeSinceLastEmail < 5*time.Minute {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":               "Please wait before requesting another email change",
+				"retry_after_seconds": int((5*time.Minute - timeSinceLastEmail).Seconds()),
+			})
+			return
+		}
+	}
+
+	// Only the newest link can switch the address
+	if err := h.emailVerificationRepo.CancelPendingEmailChanges(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create verification record",
+		})
+		return
+	}
+
+	token := services.GenerateSecureToken()
+	verification := &models.EmailVerification{
+		UserID:    user.ID,
+		Token:     token,
+		NewEmail:  &newEmail,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+	if err := h.emailVerificationRepo.Create(verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create verification record",
+		})
+		return
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+	if err := h.emailService.SendEmailChangeVerificationEmail(newEmail, fullName, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send verification email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "A confirmation link has been sent to " + newEmail + ". Your email will change once you click it.",
+	})
+}
+
+// confirmEmailChange switches the user to the address the verification was sent
+// to and tells the old address about the change
+func (h *EmailVerificationHandler) confirmEmailChange(c *gin.Context, verification *models.EmailVerification) {
+	newEmail := *verification.NewEmail
+
+	// The address may have been registered since the link was sent
+	exists, err := h.userRepo.EmailExists(newEmail)
+	if err != nil {
+		errorHTML := h.generateErrorHTML("Server Error", "An error occurred while processing your request. Please try again later.")
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(errorHTML))
+		return
+	}
+	if exists {
+		errorHTML := h.generateErrorHTML("Email Unavailable", "This email address is already used by another account. Your email address has not been changed.")
+		c.Data(http.StatusConflict, "text/html; charset=utf-8", []byte(errorHTML))
+		return
+	}
+
+	if !h.markUsed(c, verification) {
+		return
+	}
+
+	user := verification.User
+	oldEmail := user.Email
+	user.Email = newEmail
+	// Clicking the link proves the user owns the new address
+	user.IsVerified = true
+	if err := h.userRepo.Update(&user); err != nil {
+		errorHTML := h.generateErrorHTML("Server Error", "An error occurred while updating your account. Please try again later.")
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(errorHTML))
+		return
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+	go func() {
+		if err := h.emailService.SendEmailChangedEmail(oldEmail, fullName, newEmail); err != nil {
+			log.Printf("Failed to send email change notice to %s: %v", oldEmail, err)
+		}
+	}()
+
+	successHTML := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+	    <meta charset="UTF-8">
+	    <title>Email Address Changed</title>
+	    <style>
+	        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+	        .header { background-color: #28a745; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+	        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; text-align: center; }
+	        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+	    </style>
+	</head>
+	<body>
+	    <div class="header">
+	        <h1>Email Address Changed</h1>
+	    </div>
+	    <div class="content">
+	        <p>Hello %s,</p>
+	        <p>Your account now uses %s. Please use it the next time you log in.</p>
+	    </div>
+	    <div class="footer">
+	        <p>&copy; 2025 Kenyan Real Estate. All rights reserved.</p>
+	    </div>
+	</body>
+	</html>
+	`, html.EscapeString(fullName), html.EscapeString(newEmail))
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(successHTML))
+}
@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+)
+
+// invitationTTL is how long an invitation link stays valid
+const invitationTTL = 72 * time.Hour
+
+var (
+	errInvitationInvalid = errors.New("invalid or expired invitation")
+	errEmailTaken        = errors.New("an account with this email already exists")
+	errPhoneTaken        = errors.New("phone number already exists")
+	errPhoneInvalid      = errors.New("invalid phone number format")
+)
+
+// InvitationHandler handles admin and staff invitations
+type InvitationHandler struct {
+	userRepo       *models.UserRepository
+	invitationRepo *models.InvitationRepository
+	emailService   *services.EmailService
+	auditRepo      *models.AuditLogRepository
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(userRepo *models.UserRepository, invitationRepo *models.InvitationRepository, emailService *services.EmailService, auditRepo *models.AuditLogRepository) *InvitationHandler {
+	return &InvitationHandler{
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		emailService:   emailService,
+		auditRepo:      auditRepo,
+	}
+}
+
+// CreateInvitation invites a new admin, staff member or landlord (requires user:invite)
+// @Summary Invite an admin, staff member or landlord
+// @Description Email an invitation link that lets the invitee set a password. Any pending invitation for the same email is revoked.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param invitation body models.CreateInvitationRequest true "Invitation data"
+// @Success 201 {object} object{message=string,invitation=models.Invitation} "Invitation sent"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 409 {object} object{error=string} "Email already registered"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	inviter, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.IssueInvitation(req.Email, req.UserType, inviter)
+	if err != nil {
+		if errors.Is(err, errEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Email already exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to send invitation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditInvitationCreate,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   invitation.ID.String(),
+	}, nil, models.AuditSnapshot(invitation))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation,
+	})
+}
+
+// IssueInvitation creates an invitation and emails it. The inviter is nil for the
+// bootstrap invitation created at startup.
+func (h *InvitationHandler) IssueInvitation(email string, userType models.UserType, inviter *models.User) (*models.Invitation, error) {
+	emailExists, err := h.userRepo.EmailExists(email)
+	if err != nil {
+		return nil, err
+	}
+	if emailExists {
+		return nil, errEmailTaken
+	}
+
+	token := services.GenerateSecureToken()
+	invitation := &models.Invitation{
+		Email:     email,
+		UserType:  userType,
+		TokenHash: models.HashInvitationToken(token),
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+
+	inviterName := "The platform administrator"
+	if inviter != nil {
+		invitation.InvitedBy = &inviter.ID
+		inviterName = inviter.FirstName + " " + inviter.LastName
+	}
+
+	if err := h.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	if err := h.emailService.SendInvitationEmail(email, inviterName, string(userType), token, int(invitationTTL.Hours())); err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// GetPendingInvitations lists invitations that have not been accepted, revoked or expired (requires user:invite)
+// @Summary List pending invitations
+// @Description Get all invitations that can still be accepted
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{invitations=[]models.Invitation} "Pending invitations"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/invitations [get]
+func (h *InvitationHandler) GetPendingInvitations(c *gin.Context) {
+	invitations, err := h.invitationRepo.GetPending()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch invitations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitations,
+	})
+}
+
+// RevokeInvitation revokes a pending invitation (requires user:invite)
+// @Summary Revoke an invitation
+// @Description Revoke a pending invitation so its link can no longer be used
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} object{message=string} "Invitation revoked"
+// @Failure 400 {object} object{error=string} "Invalid invitation ID"
+// @Failure 404 {object} object{error=string} "Invitation not found or already used"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/invitations/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid invitation ID",
+		})
+		return
+	}
+
+	if err := h.invitationRepo.Revoke(invitationID); err != nil {
+		if errors.Is(err, models.ErrInvitationAlreadyUsed) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Invitation not found or already used",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke invitation",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditInvitationRevoke,
+		TargetType: models.AuditTargetInvitation,
+		TargetID:   invitationID.String(),
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation revoked successfully",
+	})
+}
+
+// ValidateInvitation checks an invitation token
+// @Summary Validate an invitation token
+// @Description Check that an invitation token can still be accepted
+// @Tags Authentication
+// @Produce json
+// @Param token query string true "Invitation token"
+// @Success 200 {object} object{message=string,email=string,user_type=string,expires_at=string} "Invitation is valid"
+// @Failure 400 {object} object{error=string} "Invalid or expired invitation"
+// @Router /invitations/validate [get]
+func (h *InvitationHandler) ValidateInvitation(c *gin.Context) {
+	invitation, err := h.getPendingInvitation(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired invitation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Invitation is valid",
+		"email":      invitation.Email,
+		"user_type":  invitation.UserType,
+		"expires_at": invitation.ExpiresAt,
+	})
+}
+
+// AcceptInvitation creates the invited account
+// @Summary Accept an invitation
+// @Description Set a password and create the invited admin or staff account
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.AcceptInvitationRequest true "Accept invitation request"
+// @Success 201 {object} object{message=string,user=models.UserResponse} "Account created"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request or invitation"
+// @Failure 409 {object} object{error=string} "Email or phone already exists"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /invitations/accept [post]
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var req models.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.accept(&req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invitation"})
+		case errors.Is(err, errEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		case errors.Is(err, errPhoneTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone number already exists"})
+		case errors.Is(err, errPhoneInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number format"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Account created successfully. You can now log in.",
+		"user":    user.ToResponse(),
+	})
+}
+
+// GetAcceptInvitationForm serves the HTML form for accepting an invitation
+// @Summary Get accept invitation form
+// @Description Serve HTML form for accepting an invitation
+// @Tags web
+// @Produce html
+// @Param token query string true "Invitation token"
+// @Success 200 {string} string "HTML form"
+// @Failure 400 {string} string "Invalid invitation"
+// @Router /web/accept-invitation [get]
+func (h *InvitationHandler) GetAcceptInvitationForm(c *gin.Context) {
+	token := c.Query("token")
+	invitation, err := h.getPendingInvitation(token)
+	if err != nil {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("This invitation is invalid, has expired or has already been used")))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(getAcceptInvitationFormHTML(token, invitation.Email)))
+}
+
+// PostAcceptInvitationForm handles form submission for accepting an invitation
+// @Summary Handle accept invitation form submission
+// @Description Process accept invitation form submission
+// @Tags web
+// @Accept application/x-www-form-urlencoded
+// @Produce html
+// @Param token formData string true "Invitation token"
+// @Param first_name formData string true "First name"
+// @Param last_name formData string true "Last name"
+// @Param phone_number formData string true "Phone number"
+// @Param password formData string true "Password"
+// @Param confirm_password formData string true "Confirm password"
+// @Success 201 {string} string "Success page"
+// @Failure 400 {string} string "Error page"
+// @Router /web/accept-invitation [post]
+func (h *InvitationHandler) PostAcceptInvitationForm(c *gin.Context) {
+	req := models.AcceptInvitationRequest{
+		Token:           c.PostForm("token"),
+		FirstName:       c.PostForm("first_name"),
+		LastName:        c.PostForm("last_name"),
+		PhoneNumber:     c.PostForm("phone_number"),
+		Password:        c.PostForm("password"),
+		ConfirmPassword: c.PostForm("confirm_password"),
+	}
+
+	// Validate input
+	if req.Token == "" || req.FirstName == "" || req.LastName == "" || req.PhoneNumber == "" || req.Password == "" {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("All fields are required")))
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Passwords do not match")))
+		return
+	}
+
+	if len(req.Password) < 8 {
+		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Password must be at least 8 characters long")))
+		return
+	}
+
+	if _, err := h.accept(&req); err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalid):
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("This invitation is invalid, has expired or has already been used")))
+		case errors.Is(err, errEmailTaken):
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("An account with this email already exists")))
+		case errors.Is(err, errPhoneTaken):
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Phone number already exists")))
+		case errors.Is(err, errPhoneInvalid):
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Invalid phone number format")))
+		default:
+			c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(getErrorHTML("Failed to create your account")))
+		}
+		return
+	}
+
+	c.Data(http.StatusCreated, "text/html; charset=utf-8", []byte(getSuccessHTML("Your account has been created! You can now login with your new password.")))
+}
+
+// getPendingInvitation looks up an invitation token that can still be accepted
+func (h *InvitationHandler) getPendingInvitation(token string) (*models.Invitation, error) {
+	if token == "" {
+		return nil, errInvitationInvalid
+	}
+
+	invitation, err := h.invitationRepo.GetByToken(token)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errInvitationInvalid
+		}
+		return nil, err
+	}
+
+	if !invitation.IsPending() {
+		return nil, errInvitationInvalid
+	}
+
+	return invitation, nil
+}
+
+// accept creates the invited user. The invitation proves ownership of the email,
+// so the account starts verified and approved.
+func (h *InvitationHandler) accept(req *models.AcceptInvitationRequest) (*models.User, error) {
+	invitation, err := h.getPendingInvitation(req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	emailExists, err := h.userRepo.EmailExists(invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+	if emailExists {
+		return nil, errEmailTaken
+	}
+
+	phone, err := services.NormalizePhoneNumber(req.PhoneNumber)
+	if err != nil {
+		return nil, errPhoneInvalid
+	}
+	req.PhoneNumber = phone
+
+	phoneExists, err := h.userRepo.PhoneExists(req.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if phoneExists {
+		return nil, errPhoneTaken
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:       invitation.Email,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		PhoneNumber: req.PhoneNumber,
+		UserType:    invitation.UserType,
+		IsVerified:  true,
+		IsApproved:  true,
+		ApprovedAt:  &now,
+		ApprovedBy:  invitation.InvitedBy,
+	}
+
+	if err := user.HashPassword(req.Password); err != nil {
+		return nil, err
+	}
+
+	if err := h.invitationRepo.Accept(invitation, user); err != nil {
+		if errors.Is(err, models.ErrInvitationAlreadyUsed) {
+			return nil, errInvitationInvalid
+		}
+		return nil, err
+	}
+
+	log.Printf("Invitation %s accepted: created %s account %s", invitation.ID, user.UserType, user.Email)
+	return user, nil
+}
+
+func getAcceptInvitationFormHTML(token, email string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Accept Invitation - Real Estate Platform</title>
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            background-color: #f5f5f5;
+            margin: 0;
+            padding: 20px;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+            min-height: 100vh;
+        }
+        .container {
+            background: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0, 0, 0, 0.1);
+            padding: 40px;
+            width: 100%%;
+            max-width: 400px;
+        }
+        .logo {
+            text-align: center;
+            margin-bottom: 30px;
+        }
+        .logo h1 {
+            color: #2c3e50;
+            font-size: 24px;
+            margin: 0;
+        }
+        .form-group {
+            margin-bottom: 20px;
+        }
+        label {
+            display: block;
+            margin-bottom: 5px;
+            font-weight: 600;
+            color: #333;
+        }
+        input[type="text"], input[type="tel"], input[type="password"] {
+            width: 100%%;
+            padding: 12px;
+            border: 1px solid #ddd;
+            border-radius: 4px;
+            font-size: 16px;
+            box-sizing: border-box;
+        }
+        input:focus {
+            outline: none;
+            border-color: #3498db;
+            box-shadow: 0 0 0 2px rgba(52, 152, 219, 0.2);
+        }
+        .btn {
+            width: 100%%;
+            padding: 12px;
+            background-color: #3498db;
+            color: white;
+            border: none;
+            border-radius: 4px;
+            font-size: 16px;
+            cursor: pointer;
+            transition: background-color 0.3s;
+        }
+        .btn:hover {
+            background-color: #2980b9;
+        }
+        .requirements {
+            font-size: 12px;
+            color: #666;
+            margin-top: 5px;
+        }
+        .title {
+            text-align: center;
+            margin-bottom: 10px;
+            color: #2c3e50;
+        }
+        .subtitle {
+            text-align: center;
+            margin-bottom: 30px;
+            color: #666;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="logo">
+            <h1>🏠 Real Estate Platform</h1>
+        </div>
+        <h2 class="title">Accept Your Invitation</h2>
+        <p class="subtitle">%s</p>
+        <form method="POST" action="/web/accept-invitation">
+            <input type="hidden" name="token" value="%s">
+
+            <div class="form-group">
+                <label for="first_name">First Name</label>
+                <input type="text" id="first_name" name="first_name" required>
+            </div>
+
+            <div class="form-group">
+                <label for="last_name">Last Name</label>
+                <input type="text" id="last_name" name="last_name" required>
+            </div>
+
+            <div class="form-group">
+                <label for="phone_number">Phone Number</label>
+                <input type="tel" id="phone_number" name="phone_number" required>
+            </div>
+
+            <div class="form-group">
+                <label for="password">Password</label>
+                <input type="password" id="password" name="password" required>
+                <div class="requirements">Password must be at least 8 characters long</div>
+            </div>
+
+            <div class="form-group">
+                <label for="confirm_password">Confirm Password</label>
+                <input type="password" id="confirm_password" name="confirm_password" required>
+            </div>
+
+            <button type="submit" class="btn">Create Account</button>
+        </form>
+    </div>
+</body>
+</html>
+`, html.EscapeString(email), html.EscapeString(token))
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"real-estate-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys used to verify access tokens
+type JWKSHandler struct {
+	jwtManager *auth.JWTManager
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtManager *auth.JWTManager) *JWKSHandler {
+	return &JWKSHandler{
+		jwtManager: jwtManager,
+	}
+}
+
+// GetJWKS returns the JSON Web Key Set
+// @Summary Get JSON Web Key Set
+// @Description Public keys that verify access tokens, selected by the token's kid header. Empty when tokens are signed with a shared secret.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} auth.JWKSet "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtManager.JWKS())
+}
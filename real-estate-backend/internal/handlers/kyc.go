@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"real-estate-backend/internal/config"
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// documentURLTTL is how long a signed document URL handed to a reviewer stays valid
+const documentURLTTL = 15 * time.Minute
+
+// KYCHandler handles identity and licence document uploads and their review
+type KYCHandler struct {
+	verificationRepo  *models.UserVerificationRepository
+	userRepo          *models.UserRepository
+	cloudinaryService *services.CloudinaryService
+	uploadConfig      *config.UploadConfig
+	auditRepo         *models.AuditLogRepository
+}
+
+// NewKYCHandler creates a new KYC handler
+func NewKYCHandler(
+	verificationRepo *models.UserVerificationRepository,
+	userRepo *models.UserRepository,
+	cloudinaryService *services.CloudinaryService,
+	uploadConfig *config.UploadConfig,
+	auditRepo *models.AuditLogRepository,
+) *KYCHandler {
+	return &KYCHandler{
+		verificationRepo:  verificationRepo,
+		userRepo:          userRepo,
+		cloudinaryService: cloudinaryService,
+		uploadConfig:      uploadConfig,
+		auditRepo:         auditRepo,
+	}
+}
+
+// UploadDocument uploads a KYC document for review
+// @Summary Upload a KYC document
+// @Description Upload a national ID, KRA PIN certificate or estate agent licence (JPEG, PNG, WebP or PDF, max 10MB). Documents are stored privately and reviewed by an admin. Uploading a document again replaces a pending upload of the same type.
+// @Tags KYC
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param document_type formData string true "Document type" Enums(national_id,kra_pin,estate_agent_licence)
+// @Param file formData file true "Document file"
+// @Success 201 {object} object{message=string,document=models.VerificationResponse} "Document uploaded"
+// @Failure 400 {object} object{error=string} "Invalid document type or file"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Documents are not required for this account"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /kyc/documents [post]
+func (h *KYCHandler) UploadDocument(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if len(models.RequiredKYCDocuments[user.UserType]) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Documents are not required for this account",
+		})
+		return
+	}
+
+	documentType := models.KYCDocumentType(c.PostForm("document_type"))
+	if !documentType.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Invalid document type",
+			"required_types": models.RequiredKYCDocuments[user.UserType],
+		})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Document file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if err := h.cloudinaryService.ValidateDocumentFile(header, h.uploadConfig.AllowedTypes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	uploadResponse, err := h.cloudinaryService.UploadPrivateDocument(c.Request.Context(), file, header, user.ID, string(documentType))
+	if err != nil {
+		log.Printf("Failed to upload KYC document for user %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload document",
+		})
+		return
+	}
+
+	// Only the newest upload of each document type is reviewed
+	if err := h.verificationRepo.ExpirePendingDocuments(user.ID, documentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save document",
+		})
+		return
+	}
+
+	docType := string(documentType)
+	verification := &models.UserVerification{
+		UserID:           user.ID,
+		Type:             models.VerificationTypeDocument,
+		Status:           models.VerificationStatusPending,
+		DocumentType:     &docType,
+		DocumentPublicID: &uploadResponse.PublicID,
+		DocumentFormat:   &uploadResponse.Format,
+	}
+	if err := h.verificationRepo.Create(verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save document",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Document uploaded and awaiting review",
+		"document": verification.ToResponse(),
+	})
+}
+
+// GetMyDocuments lists the authenticated user's KYC documents
+// @Summary Get my KYC documents
+// @Description Get the documents the user has uploaded with their review status and notes, and the required documents not verified yet
+// @Tags KYC
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{documents=[]models.VerificationResponse,missing=[]string} "Documents"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /kyc/documents [get]
+func (h *KYCHandler) GetMyDocuments(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	documents, err := h.verificationRepo.GetDocuments(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get documents",
+		})
+		return
+	}
+
+	missing, err := h.verificationRepo.GetMissingDocuments(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get documents",
+		})
+		return
+	}
+
+	responses := make([]*models.VerificationResponse, len(documents))
+	for i, document := range documents {
+		responses[i] = document.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": responses,
+		"missing":   missing,
+	})
+}
+
+// GetPendingDocuments lists documents waiting for review (requires agent:approve)
+// @Summary Get the KYC review queue
+// @Description Get pending documents, oldest first. Each document has a signed URL that expires after 15 minutes.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{documents=[]models.KYCDocumentResponse} "Pending documents"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/kyc/documents [get]
+func (h *KYCHandler) GetPendingDocuments(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	documentType := models.VerificationTypeDocument
+	documents, err := h.verificationRepo.GetPendingVerifications(&documentType, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get pending documents",
+		})
+		return
+	}
+
+	responses := make([]models.KYCDocumentResponse, 0, len(documents))
+	for _, document := range documents {
+		response, err := h.documentResponse(document)
+		if err != nil {
+			log.Printf("Failed to sign URL for document %s: %v", document.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get pending documents",
+			})
+			return
+		}
+		responses = append(responses, *response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": responses,
+	})
+}
+
+// ReviewDocument approves or rejects a document (requires agent:approve)
+// @Summary Review a KYC document
+// @Description Mark a pending document as verified or rejected, with notes shown to the user
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Document ID"
+// @Param request body models.AdminVerificationRequest true "Review decision"
+// @Success 200 {object} object{message=string,document=models.VerificationResponse} "Document reviewed"
+// @Failure 400 {object} object{error=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Document not found"
+// @Failure 409 {object} object{error=string} "Document is not pending review"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/kyc/documents/{id}/review [post]
+func (h *KYCHandler) ReviewDocument(c *gin.Context) {
+	adminID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid document ID",
+		})
+		return
+	}
+
+	var req models.AdminVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	document, err := h.verificationRepo.GetByID(id)
+	if err != nil || document.Type != models.VerificationTypeDocument {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Document not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get document",
+		})
+		return
+	}
+
+	if document.Status != models.VerificationStatusPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Document is not pending review",
+		})
+		return
+	}
+
+	before := models.AuditSnapshot(document.ToResponse())
+
+	now := time.Now()
+	document.Status = req.Status
+	document.VerifiedAt = &now
+	document.VerifiedBy = &adminID
+	if req.Notes != "" {
+		document.Notes = &req.Notes
+	}
+	if err := h.verificationRepo.SaveReview(document); err != nil {
+		if errors.Is(err, models.ErrDocumentNotPending) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Document is not pending review",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update document",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditKYCReview,
+		TargetType: models.AuditTargetVerification,
+		TargetID:   document.ID.String(),
+	}, before, models.AuditSnapshot(document.ToResponse()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Document " + string(req.Status),
+		"document": document.ToResponse(),
+	})
+}
+
+// documentResponse builds a review queue entry with a short-lived signed URL
+func (h *KYCHandler) documentResponse(document *models.UserVerification) (*models.KYCDocumentResponse, error) {
+	response := &models.KYCDocumentResponse{
+		VerificationResponse: *document.ToResponse(),
+	}
+	if document.User.ID != uuid.Nil {
+		response.User = document.User.ToResponse()
+	}
+	if document.DocumentPublicID != nil {
+		format := ""
+		if document.DocumentFormat != nil {
+			format = *document.DocumentFormat
+		}
+		url, err := h.cloudinaryService.PrivateDocumentURL(*document.DocumentPublicID, format, documentURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		response.DocumentURL = url
+	}
+	return response, nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler lets admins see and clear brute-force lockouts
+type LockoutHandler struct {
+	loginThrottle *services.LoginThrottle
+	auditRepo     *models.AuditLogRepository
+}
+
+// NewLockoutHandler creates a new lockout handler
+func NewLockoutHandler(loginThrottle *services.LoginThrottle, auditRepo *models.AuditLogRepository) *LockoutHandler {
+	return &LockoutHandler{
+		loginThrottle: loginThrottle,
+		auditRepo:     auditRepo,
+	}
+}
+
+// ClearLockoutRequest represents the request to clear a lockout
+type ClearLockoutRequest struct {
+	Key string `json:"key" binding:"required"` // e.g. "account:jane@example.com" or "ip:203.0.113.7"
+}
+
+// GetLockouts lists locked accounts and IP addresses (requires security:manage)
+// @Summary List lockouts
+// @Description Get the accounts and IP addresses that are locked after repeated failed attempts
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{lockouts=[]models.LoginAttempt} "Current lockouts"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/lockouts [get]
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.loginThrottle.GetLocks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get lockouts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lockouts": lockouts,
+	})
+}
+
+// ClearLockout clears the failures and lock on a key (requires security:manage)
+// @Summary Clear a lockout
+// @Description Unlock an account or IP address and reset its failed attempts
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body handlers.ClearLockoutRequest true "Lockout key"
+// @Success 200 {object} object{message=string} "Lockout cleared"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/lockouts/clear [post]
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	var req ClearLockoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.loginThrottle.Reset(req.Key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to clear lockout",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditLockoutClear,
+		TargetType: models.AuditTargetLockout,
+		TargetID:   req.Key,
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lockout cleared successfully",
+	})
+}
+
+// respondTooManyAttempts writes a 429 response telling the client how long to wait
+func respondTooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":               "Too many failed attempts. Please try again later.",
+		"retry_after_seconds": seconds,
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OwnerHandler gives landlords read access to the properties they own and the
+// leases and payments on them. Agents manage the properties; landlords cannot
+// change them.
+type OwnerHandler struct {
+	propertyRepo *models.PropertyRepository
+	leaseRepo    *models.LeaseRepository
+	paymentRepo  *models.PaymentRepository
+}
+
+// NewOwnerHandler creates a new owner handler
+func NewOwnerHandler(propertyRepo *models.PropertyRepository, leaseRepo *models.LeaseRepository, paymentRepo *models.PaymentRepository) *OwnerHandler {
+	return &OwnerHandler{
+		propertyRepo: propertyRepo,
+		leaseRepo:    leaseRepo,
+		paymentRepo:  paymentRepo,
+	}
+}
+
+// GetMyUnits lists the properties the landlord owns (requires owner:read)
+// @Summary Get my units
+// @Description Get the properties owned by the authenticated landlord, with the agent managing each
+// @Tags Owner
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{properties=[]models.Property,limit=int,offset=int} "Owned properties"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /owner/properties [get]
+func (h *OwnerHandler) GetMyUnits(c *gin.Context) {
+	ownerID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	properties, err := h.propertyRepo.GetByOwnerID(ownerID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get properties",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"properties": properties,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// GetMyLeases lists the leases on the landlord's properties (requires owner:read)
+// @Summary Get leases on my units
+// @Description Get the leases on the properties owned by the authenticated landlord
+// @Tags Owner
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{leases=[]models.Lease} "Leases"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /owner/leases [get]
+func (h *OwnerHandler) GetMyLeases(c *gin.Context) {
+	ownerID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	leases, err := h.leaseRepo.GetByOwnerID(ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leases",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leases": leases,
+	})
+}
+
+// GetMyPayments lists the payments on the landlord's leases (requires owner:read)
+// @Summary Get payments on my units
+// @Description Get every payment, in any status, on the leases of the authenticated landlord's properties
+// @Tags Owner
+// @Produce json
+// @Security Bearer
+// @Param from query string false "Paid on or after (YYYY-MM-DD), default 30 days before to"
+// @Param to query string false "Paid on or before (YYYY-MM-DD), default today"
+// @Success 200 {object} object{payments=[]models.Payment,from=string,to=string} "Payments"
+// @Failure 400 {object} object{error=string} "Invalid date range"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /owner/payments [get]
+func (h *OwnerHandler) GetMyPayments(c *gin.Context) {
+	ownerID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	from, to, ok := bindDateRange(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.paymentRepo.GetByOwnerID(ownerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get payments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"from":     from,
+		"to":       to,
+	})
+}
+
+// GetMyStatement totals the payments collected on the landlord's properties (requires owner:read)
+// @Summary Get my statement
+// @Description Get the completed payments on each of the authenticated landlord's properties, totalled by payment type, for the period
+// @Tags Owner
+// @Produce json
+// @Security Bearer
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days before to"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD), default today"
+// @Success 200 {object} models.OwnerStatement "Statement"
+// @Failure 400 {object} object{error=string} "Invalid date range"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /owner/statement [get]
+func (h *OwnerHandler) GetMyStatement(c *gin.Context) {
+	ownerID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	from, to, ok := bindDateRange(c)
+	if !ok {
+		return
+	}
+
+	statement, err := h.paymentRepo.GetOwnerStatement(ownerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to build statement",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -19,15 +20,19 @@ import (
 type PasswordResetHandler struct {
 	userRepo          *models.UserRepository
 	passwordResetRepo *models.PasswordResetRepository
+	loginThrottle     *services.LoginThrottle
 	emailService      *services.EmailService
+	auditRepo         *models.AuditLogRepository
 }
 
 // NewPasswordResetHandler creates a new password reset handler
-func NewPasswordResetHandler(userRepo *models.UserRepository, passwordResetRepo *models.PasswordResetRepository, emailService *services.EmailService) *PasswordResetHandler {
+func NewPasswordResetHandler(userRepo *models.UserRepository, passwordResetRepo *models.PasswordResetRepository, loginThrottle *services.LoginThrottle, emailService *services.EmailService, auditRepo *models.AuditLogRepository) *PasswordResetHandler {
 	return &PasswordResetHandler{
 		userRepo:          userRepo,
 		passwordResetRepo: passwordResetRepo,
+		loginThrottle:     loginThrottle,
 		emailService:      emailService,
+		auditRepo:         auditRepo,
 	}
 }
 
@@ -41,6 +46,7 @@ func NewPasswordResetHandler(userRepo *models.UserRepository, passwordResetRepo
 // @Success 200 {object} map[string]interface{} "Password reset email sent"
 // @Failure 400 {object} map[string]interface{} "Invalid request"
 // @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 429 {object} map[string]interface{} "Too many requests"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/forgot-password [post]
 func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
@@ -50,6 +56,21 @@ func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
+	// Every request counts as an attempt, so reset emails cannot be used to flood an inbox
+	resetKey := services.PasswordResetAttemptKey(req.Email)
+	ipKey := services.PasswordResetIPAttemptKey(c.ClientIP())
+	wait, err := h.loginThrottle.Check(resetKey, ipKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+	h.recordFailure(resetKey)
+	h.recordFailure(ipKey)
+
 	// Check if user exists
 	user, err := h.userRepo.GetByEmail(req.Email)
 	if err != nil {
@@ -113,6 +134,7 @@ func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
 // @Success 200 {object} map[string]interface{} "Password reset successful"
 // @Failure 400 {object} map[string]interface{} "Invalid request or token"
 // @Failure 404 {object} map[string]interface{} "Token not found"
+// @Failure 429 {object} map[string]interface{} "Too many failed attempts"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/reset-password [post]
 func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
@@ -122,10 +144,21 @@ func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	wait, err := h.checkTokenAttempts(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate reset token"})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
 	// Get password reset token
 	passwordReset, err := h.passwordResetRepo.GetByTokenWithUser(req.Token)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
+			h.recordFailure(services.IPAttemptKey(c.ClientIP()))
 			c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired reset token"})
 			return
 		}
@@ -159,6 +192,12 @@ func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// Invalidate every token issued with the old password
+	if err := h.userRepo.RevokeAllTokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke existing sessions"})
+		return
+	}
+
 	// Mark token as used
 	passwordReset.MarkAsUsed()
 	err = h.passwordResetRepo.Update(passwordReset)
@@ -167,6 +206,17 @@ func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// Proving control of the mailbox unlocks the account
+	h.clearAccountLock(user.Email)
+
+	// The reset is unauthenticated; the token holder acts as the user
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		ActorID:    &user.ID,
+		Action:     models.AuditPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+	}, nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password reset successful",
 	})
@@ -225,8 +275,20 @@ func (h *PasswordResetHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// Invalidate every token issued with the old password, including the current one
+	if err := h.userRepo.RevokeAllTokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke existing sessions"})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPasswordChange,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+	}, nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Password changed successfully",
+		"message": "Password changed successfully. Please log in again.",
 	})
 }
 
@@ -240,6 +302,7 @@ func (h *PasswordResetHandler) ChangePassword(c *gin.Context) {
 // @Success 200 {object} map[string]interface{} "Token is valid"
 // @Failure 400 {object} map[string]interface{} "Invalid or expired token"
 // @Failure 404 {object} map[string]interface{} "Token not found"
+// @Failure 429 {object} map[string]interface{} "Too many failed attempts"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/validate-reset-token [get]
 func (h *PasswordResetHandler) ValidateResetToken(c *gin.Context) {
@@ -249,10 +312,21 @@ func (h *PasswordResetHandler) ValidateResetToken(c *gin.Context) {
 		return
 	}
 
+	wait, err := h.checkTokenAttempts(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
 	// Get password reset token
 	passwordReset, err := h.passwordResetRepo.GetByToken(token)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
+			h.recordFailure(services.IPAttemptKey(c.ClientIP()))
 			c.JSON(http.StatusNotFound, gin.H{"error": "Invalid reset token"})
 			return
 		}
@@ -278,6 +352,25 @@ func (h *PasswordResetHandler) ValidateResetToken(c *gin.Context) {
 	})
 }
 
+// checkTokenAttempts returns how long the client IP must wait after too many invalid reset tokens
+func (h *PasswordResetHandler) checkTokenAttempts(c *gin.Context) (time.Duration, error) {
+	return h.loginThrottle.Check(services.IPAttemptKey(c.ClientIP()))
+}
+
+// recordFailure counts an attempt against a throttling key
+func (h *PasswordResetHandler) recordFailure(key string) {
+	if _, _, err := h.loginThrottle.RecordFailure(key); err != nil {
+		log.Printf("Failed to record attempt for %s: %v", key, err)
+	}
+}
+
+// clearAccountLock forgets failed logins for an account after its password was reset
+func (h *PasswordResetHandler) clearAccountLock(email string) {
+	if err := h.loginThrottle.Reset(services.AccountAttemptKey(email)); err != nil {
+		log.Printf("Failed to clear login attempts for %s: %v", email, err)
+	}
+}
+
 // CleanupExpiredTokens removes expired tokens (can be called periodically)
 func (h *PasswordResetHandler) CleanupExpiredTokens() error {
 	return h.passwordResetRepo.DeleteExpiredTokens()
@@ -309,9 +402,15 @@ func (h *PasswordResetHandler) GetResetPasswordForm(c *gin.Context) {
 		return
 	}
 
+	if wait, err := h.checkTokenAttempts(c); err != nil || wait > 0 {
+		c.Data(http.StatusTooManyRequests, "text/html; charset=utf-8", []byte(getErrorHTML("Too many failed attempts. Please try again later.")))
+		return
+	}
+
 	// Validate token
 	passwordReset, err := h.passwordResetRepo.GetByToken(token)
 	if err != nil {
+		h.recordFailure(services.IPAttemptKey(c.ClientIP()))
 		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Invalid or expired reset token")))
 		return
 	}
@@ -364,9 +463,15 @@ func (h *PasswordResetHandler) PostResetPasswordForm(c *gin.Context) {
 		ConfirmPassword: confirmPassword,
 	}
 
+	if wait, err := h.checkTokenAttempts(c); err != nil || wait > 0 {
+		c.Data(http.StatusTooManyRequests, "text/html; charset=utf-8", []byte(getErrorHTML("Too many failed attempts. Please try again later.")))
+		return
+	}
+
 	// Process reset using the same logic as API
 	passwordReset, err := h.passwordResetRepo.GetByTokenWithUser(req.Token)
 	if err != nil {
+		h.recordFailure(services.IPAttemptKey(c.ClientIP()))
 		c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(getErrorHTML("Invalid or expired reset token")))
 		return
 	}
@@ -390,6 +495,12 @@ func (h *PasswordResetHandler) PostResetPasswordForm(c *gin.Context) {
 		return
 	}
 
+	// Invalidate every token issued with the old password
+	if err := h.userRepo.RevokeAllTokens(user.ID); err != nil {
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(getErrorHTML("Failed to process password reset")))
+		return
+	}
+
 	// Mark token as used
 	passwordReset.MarkAsUsed()
 	err = h.passwordResetRepo.Update(passwordReset)
@@ -398,6 +509,17 @@ func (h *PasswordResetHandler) PostResetPasswordForm(c *gin.Context) {
 		return
 	}
 
+	// Proving control of the mailbox unlocks the account
+	h.clearAccountLock(user.Email)
+
+	// The reset is unauthenticated; the token holder acts as the user
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		ActorID:    &user.ID,
+		Action:     models.AuditPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+	}, nil, nil)
+
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(getSuccessHTML("Password reset successful! You can now login with your new password.")))
 }
 
@@ -54,6 +54,20 @@ func (h *PaymentHandler) InitiateRentPayment(c *gin.Context) {
 		return
 	}
 
+	// The /payments group applies RequireVerifiedPhone, but check here as well so
+	// the rule holds wherever this handler is mounted
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsPhoneVerified {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                       "Phone verification required. Please verify your phone number before making payments.",
+			"phone_verification_required": true,
+		})
+		return
+	}
+
 	var req InitiateRentPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
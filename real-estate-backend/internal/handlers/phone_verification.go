@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Phone verification limits
+const (
+	phoneCodeDigits      = 6
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeCooldown    = time.Minute // Minimum time between two codes
+	phoneCodesPerHour    = 5
+	phoneCodeMaxAttempts = 5 // Wrong codes before the code is burned
+)
+
+// PhoneVerificationHandler handles phone number verification by SMS one-time code
+type PhoneVerificationHandler struct {
+	userRepo         *models.UserRepository
+	verificationRepo *models.UserVerificationRepository
+	smsSender        services.SMSSender
+}
+
+// NewPhoneVerificationHandler creates a new phone verification handler
+func NewPhoneVerificationHandler(
+	userRepo *models.UserRepository,
+	verificationRepo *models.UserVerificationRepository,
+	smsSender services.SMSSender,
+) *PhoneVerificationHandler {
+	return &PhoneVerificationHandler{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		smsSender:        smsSender,
+	}
+}
+
+// SendPhoneVerification sends a one-time code to a phone number by SMS
+// @Summary Send phone verification code
+// @Description Send a 6-digit code by SMS to the given phone number. Codes expire after 10 minutes; a new code can be requested once a minute and at most 5 times an hour. Verifying the code makes the number the user's phone number.
+// @Tags Phone Verification
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.PhoneVerificationRequest true "Phone number"
+// @Success 200 {object} object{message=string,phone_number=string,expires_in=int} "Verification code sent"
+// @Failure 400 {object} object{error=string} "Invalid phone number or already verified"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 409 {object} object{error=string} "Phone number belongs to another account"
+// @Failure 429 {object} object{error=string,retry_after_seconds=int} "Too many requests"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /send-phone-verification [post]
+func (h *PhoneVerificationHandler) SendPhoneVerification(c *gin.Context) {
+	var req models.PhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	phone, err := services.NormalizePhoneNumber(req.PhoneNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid phone number format",
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	currentPhone, _ := services.NormalizePhoneNumber(user.PhoneNumber)
+	if phone == currentPhone {
+		if user.IsPhoneVerified {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Phone number is already verified",
+			})
+			return
+		}
+	} else {
+		exists, err := h.userRepo.PhoneExists(phone)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check phone number",
+			})
+			return
+		}
+		if exists {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Phone number is already registered to another account",
+			})
+			return
+		}
+	}
+
+	// Throttle resends
+	latest, err := h.verificationRepo.GetByUserAndType(user.ID, models.VerificationTypePhone)
+	if err == nil {
+		if sinceLast := time.Since(latest.CreatedAt); sinceLast < phoneCodeCooldown {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":               "Please wait before requesting another code",
+				"retry_after_seconds": int((phoneCodeCooldown - sinceLast).Seconds()) + 1,
+			})
+			return
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check previous codes",
+		})
+		return
+	}
+
+	sent, err := h.verificationRepo.CountCreatedSince(user.ID, models.VerificationTypePhone, time.Now().Add(-time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check previous codes",
+		})
+		return
+	}
+	if sent >= phoneCodesPerHour {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":               "Too many codes requested. Please try again later.",
+			"retry_after_seconds": int(time.Hour.Seconds()),
+		})
+		return
+	}
+
+	code, err := services.GenerateOTPCode(phoneCodeDigits)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate code",
+		})
+		return
+	}
+
+	// Only the newest code is valid
+	if err := h.verificationRepo.ExpirePendingForUser(user.ID, models.VerificationTypePhone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create verification record",
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(phoneCodeTTL)
+	verification := &models.UserVerification{
+		UserID:    user.ID,
+		Type:      models.VerificationTypePhone,
+		Status:    models.VerificationStatusPending,
+		Code:      &code,
+		Target:    &phone,
+		ExpiresAt: &expiresAt,
+	}
+	if err := h.verificationRepo.Create(verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create verification record",
+		})
+		return
+	}
+
+	message := fmt.Sprintf("Your Real Estate Platform verification code is %s. It expires in %d minutes.",
+		code, int(phoneCodeTTL.Minutes()))
+	if err := h.smsSender.Send(phone, message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send verification code",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Verification code sent",
+		"phone_number": phone,
+		"expires_in":   int(phoneCodeTTL.Seconds()),
+	})
+}
+
+// VerifyPhone verifies the user's phone number with the code sent by SMS
+// @Summary Verify phone number
+// @Description Verify the phone number with the code sent by SMS. After 5 wrong codes a new code must be requested.
+// @Tags Phone Verification
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.VerifyCodeRequest true "Verification code with type \"phone\""
+// @Success 200 {object} object{message=string,phone_number=string} "Phone number verified"
+// @Failure 400 {object} object{error=string,attempts_remaining=int} "Invalid or expired code"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 429 {object} object{error=string} "Too many wrong codes"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /verify-phone [post]
+func (h *PhoneVerificationHandler) VerifyPhone(c *gin.Context) {
+	var req models.VerifyCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Type != models.VerificationTypePhone {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unsupported verification type",
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	verification, err := h.verificationRepo.GetByUserAndType(user.ID, models.VerificationTypePhone)
+	if err != nil || verification.Status != models.VerificationStatusPending || verification.Code == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No pending verification code. Please request a new code.",
+		})
+		return
+	}
+
+	if verification.IsExpired() {
+		verification.Status = models.VerificationStatusExpired
+		h.verificationRepo.Update(verification)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Verification code has expired. Please request a new code.",
+		})
+		return
+	}
+
+	// Count the attempt before comparing, so parallel guesses share the limit
+	attempts, allowed, err := h.verificationRepo.UseAttempt(verification.ID, phoneCodeMaxAttempts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many wrong codes. Please request a new code.",
+		})
+		return
+	}
+	verification.Attempts = attempts
+
+	if subtle.ConstantTimeCompare([]byte(*verification.Code), []byte(req.Code)) != 1 {
+		remaining := phoneCodeMaxAttempts - attempts
+		if remaining <= 0 {
+			verification.Status = models.VerificationStatusExpired
+			h.verificationRepo.Update(verification)
+			remaining = 0
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":              "Invalid verification code",
+			"attempts_remaining": remaining,
+		})
+		return
+	}
+
+	now := time.Now()
+	verification.Status = models.VerificationStatusVerified
+	verification.VerifiedAt = &now
+	if err := h.verificationRepo.Update(verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update verification record",
+		})
+		return
+	}
+
+	if err := h.userRepo.MarkPhoneVerified(user.ID, *verification.Target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update phone number",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Phone number verified successfully",
+		"phone_number": *verification.Target,
+	})
+}
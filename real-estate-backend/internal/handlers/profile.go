@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"real-estate-backend/internal/config"
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileHandler handles users editing their own profile
+type ProfileHandler struct {
+	userRepo          *models.UserRepository
+	cloudinaryService *services.CloudinaryService
+	uploadConfig      *config.UploadConfig
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(userRepo *models.UserRepository, cloudinaryService *services.CloudinaryService, uploadConfig *config.UploadConfig) *ProfileHandler {
+	return &ProfileHandler{
+		userRepo:          userRepo,
+		cloudinaryService: cloudinaryService,
+		uploadConfig:      uploadConfig,
+	}
+}
+
+// UpdateProfile updates the authenticated user's name and phone number
+// @Summary Update user profile
+// @Description Update the authenticated user's name and phone number. Phone numbers are stored in 2547XXXXXXXX form; changing the number clears its verification.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} object{message=string,user=models.UserResponse} "Profile updated"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 409 {object} object{error=string} "Phone number belongs to another account"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /profile [put]
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	if req.FirstName != nil {
+		firstName := strings.TrimSpace(*req.FirstName)
+		if firstName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "First name cannot be empty",
+			})
+			return
+		}
+		user.FirstName = firstName
+	}
+	if req.LastName != nil {
+		lastName := strings.TrimSpace(*req.LastName)
+		if lastName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Last name cannot be empty",
+			})
+			return
+		}
+		user.LastName = lastName
+	}
+
+	if req.PhoneNumber != nil {
+		phone, err := services.NormalizePhoneNumber(*req.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid phone number format",
+			})
+			return
+		}
+
+		currentPhone, _ := services.NormalizePhoneNumber(user.PhoneNumber)
+		if phone != currentPhone {
+			exists, err := h.userRepo.PhoneExists(phone)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to check phone number",
+				})
+				return
+			}
+			if exists {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "Phone number is already registered to another account",
+				})
+				return
+			}
+
+			// A new number has to be verified again
+			user.IsPhoneVerified = false
+			user.PhoneVerifiedAt = nil
+		}
+		user.PhoneNumber = phone
+	}
+
+	if err := h.userRepo.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// UploadProfilePhoto replaces the authenticated user's profile photo
+// @Summary Upload profile photo
+// @Description Upload a profile photo (JPEG, PNG or WebP, max 10MB). It is cropped to a 400x400 square centred on the face. The previous photo is deleted.
+// @Tags Users
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param photo formData file true "Photo"
+// @Success 200 {object} object{message=string,user=models.UserResponse} "Photo uploaded"
+// @Failure 400 {object} object{error=string} "Invalid file"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /profile/photo [post]
+func (h *ProfileHandler) UploadProfilePhoto(c *gin.Context) {
+	file, header, err := c.Request.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Photo file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if err := h.cloudinaryService.ValidateImageFile(header, h.uploadConfig.AllowedTypes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	uploadResponse, err := h.cloudinaryService.UploadProfilePhoto(c.Request.Context(), file, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload photo",
+		})
+		return
+	}
+
+	oldPublicID := user.ProfileImageID
+	user.ProfileImageURL = &uploadResponse.SecureURL
+	user.ProfileImageID = &uploadResponse.PublicID
+	if err := h.userRepo.Update(user); err != nil {
+		// Don't leave the new upload orphaned
+		h.deletePhoto(c, user.ProfileImageID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update profile",
+		})
+		return
+	}
+
+	h.deletePhoto(c, oldPublicID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile photo uploaded successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// DeleteProfilePhoto removes the authenticated user's profile photo
+// @Summary Delete profile photo
+// @Description Remove the profile photo and delete it from storage
+// @Tags Users
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{message=string,user=models.UserResponse} "Photo removed"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /profile/photo [delete]
+func (h *ProfileHandler) DeleteProfilePhoto(c *gin.Context) {
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	oldPublicID := user.ProfileImageID
+	user.ProfileImageURL = nil
+	user.ProfileImageID = nil
+	if err := h.userRepo.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update profile",
+		})
+		return
+	}
+
+	h.deletePhoto(c, oldPublicID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile photo removed successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// loadUser loads a fresh copy of the authenticated user to update, writing an
+// error response if it cannot
+func (h *ProfileHandler) loadUser(c *gin.Context) (*models.User, bool) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// deletePhoto deletes a profile photo from storage. Failures are only logged:
+// the profile no longer points at the photo.
+func (h *ProfileHandler) deletePhoto(c *gin.Context, publicID *string) {
+	if publicID == nil {
+		return
+	}
+	if err := h.cloudinaryService.DeleteImage(c.Request.Context(), *publicID); err != nil {
+		log.Printf("Failed to delete profile photo %s: %v", *publicID, err)
+	}
+}
@@ -2,46 +2,60 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"real-estate-backend/internal/config"
+	"real-estate-backend/internal/middleware"
 	"real-estate-backend/internal/models"
 	"real-estate-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // PropertyHandler handles property-related HTTP requests
 type PropertyHandler struct {
 	propertyRepo      *models.PropertyRepository
 	propertyImageRepo *models.PropertyImageRepository
+	userRepo          *models.UserRepository
 	cloudinaryService *services.CloudinaryService
 	uploadConfig      *config.UploadConfig
+	auditRepo         *models.AuditLogRepository
+	reviewRepo        *models.ReviewRepository
+	editsNeedReview   bool // Send published listings back for review when key fields or photos change
 }
 
 // NewPropertyHandler creates a new property handler
-func NewPropertyHandler(propertyRepo *models.PropertyRepository, propertyImageRepo *models.PropertyImageRepository, cloudinaryService *services.CloudinaryService, uploadConfig *config.UploadConfig) *PropertyHandler {
+func NewPropertyHandler(propertyRepo *models.PropertyRepository, propertyImageRepo *models.PropertyImageRepository, userRepo *models.UserRepository, cloudinaryService *services.CloudinaryService, uploadConfig *config.UploadConfig, auditRepo *models.AuditLogRepository, reviewRepo *models.ReviewRepository, editsNeedReview bool) *PropertyHandler {
 	return &PropertyHandler{
 		propertyRepo:      propertyRepo,
 		propertyImageRepo: propertyImageRepo,
+		userRepo:          userRepo,
 		cloudinaryService: cloudinaryService,
 		uploadConfig:      uploadConfig,
+		auditRepo:         auditRepo,
+		reviewRepo:        reviewRepo,
+		editsNeedReview:   editsNeedReview,
 	}
 }
 
 // CreateProperty handles property creation (agent only)
 // @Summary Create a new property
-// @Description Create a new property listing (agent only)
+// @Description Create a new property listing (agent only). It is saved as a draft, or with submit set, goes to the moderation queue; it is public once a moderator approves it.
 // @Tags Properties
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param property body models.CreatePropertyRequest true "Property data"
-// @Success 201 {object} object{message=string,property=models.Property} "Property created successfully"
+// @Success 201 {object} object{message=string,property=models.Property} "Property saved as a draft or submitted for review"
 // @Failure 400 {object} object{error=string,details=string} "Invalid request data"
 // @Failure 401 {object} object{error=string} "Unauthorized"
 // @Failure 500 {object} object{error=string} "Internal server error"
@@ -72,9 +86,28 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 		return
 	}
 
-	// Create property
+	if err := req.Amenities.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid amenities",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.OwnerID != nil && !h.checkOwner(c, *req.OwnerID) {
+		return
+	}
+
+	// Listings of agency agents join the agency's portfolio
+	var agencyID *uuid.UUID
+	if user, ok := middleware.GetCurrentUser(c); ok {
+		agencyID = user.AgencyID
+	}
+
+	// Create property as a draft, or submitted for review
 	property := &models.Property{
 		AgentID:           agentID,
+		AgencyID:          agencyID,
 		Title:             req.Title,
 		Description:       req.Description,
 		PropertyType:      req.PropertyType,
@@ -93,6 +126,11 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 		ParkingSpaces:     req.ParkingSpaces,
 		IsFurnished:       req.IsFurnished,
 		AvailabilityDate:  req.AvailabilityDate,
+		OwnerID:           req.OwnerID,
+	}
+	property.SetStatus(models.PropertyStatusDraft)
+	if req.Submit {
+		property.SetStatus(models.PropertyStatusPendingReview)
 	}
 
 	if err := h.propertyRepo.Create(property); err != nil {
@@ -102,15 +140,25 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyCreate,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, nil, models.AuditSnapshot(property, propertyAuditOmit...))
+
+	message := "Property saved as a draft"
+	if req.Submit {
+		message = "Property submitted for review"
+	}
 	c.JSON(http.StatusCreated, gin.H{
-		"message":  "Property created successfully",
+		"message":  message,
 		"property": property,
 	})
 }
 
 // GetProperty handles getting a single property by ID
 // @Summary Get a property by ID
-// @Description Get detailed information about a specific property including images
+// @Description Get detailed information about a published property including images and its rating from tenant reviews. Listings of suspended agents are not found. Agents see their unpublished listings through /my-properties.
 // @Tags Properties
 // @Accept json
 // @Produce json
@@ -130,9 +178,10 @@ func (h *PropertyHandler) GetProperty(c *gin.Context) {
 		return
 	}
 
-	property, err := h.propertyRepo.GetByID(propertyID)
+	// Only published listings of agents who are not suspended are public
+	property, err := h.propertyRepo.GetPublishedByID(propertyID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Property not found",
 			})
@@ -152,6 +201,11 @@ func (h *PropertyHandler) GetProperty(c *gin.Context) {
 	}
 	property.Images = images
 
+	// Attach the rating from tenant reviews
+	if ratings, err := h.reviewRepo.GetSummaries(models.ReviewTargetProperty, []uuid.UUID{property.ID}); err == nil {
+		property.Rating = ratings[property.ID]
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"property": property,
 	})
@@ -159,10 +213,11 @@ func (h *PropertyHandler) GetProperty(c *gin.Context) {
 
 // GetPublicProperties handles getting public property listings with search and filtering
 // @Summary Get public property listings
-// @Description Get a list of available properties with optional filtering and pagination
+// @Description Get a list of published properties with optional filtering and pagination. With q, only matching properties are returned, most relevant first, each with a search_rank and a search_snippet highlighting the matches.
 // @Tags Properties
 // @Accept json
 // @Produce json
+// @Param q query string false "Full-text search over title, description, location details and county and sub-county names"
 // @Param county_id query int false "Filter by county ID"
 // @Param sub_county_id query int false "Filter by sub-county ID"
 // @Param property_type query string false "Filter by property type" Enums(apartment,house,bedsitter,studio,maisonette,bungalow,villa,commercial)
@@ -173,15 +228,171 @@ func (h *PropertyHandler) GetProperty(c *gin.Context) {
 // @Param min_bathrooms query int false "Minimum number of bathrooms"
 // @Param is_furnished query boolean false "Filter by furnished status"
 // @Param has_parking query boolean false "Filter by parking availability"
+// @Param amenities query string false "Comma-separated amenity keys from GET /amenities; only properties with all of them"
+// @Param lat query number false "Latitude of the point to measure distances from; requires lng"
+// @Param lng query number false "Longitude of the point to measure distances from; requires lat"
+// @Param radius_km query number false "Only properties within this many km of lat,lng (at most 500)"
+// @Param min_lat query number false "South edge of the map bounding box; requires all four edges"
+// @Param min_lng query number false "West edge of the map bounding box"
+// @Param max_lat query number false "North edge of the map bounding box"
+// @Param max_lng query number false "East edge of the map bounding box"
+// @Param sort query string false "Sort order; relevance needs q and distance needs lat,lng or a bounding box. Defaults to relevance with q, otherwise newest" Enums(relevance,newest,price_asc,price_desc,bedrooms_asc,bedrooms_desc,distance)
 // @Param limit query int false "Number of results per page" default(20)
-// @Param offset query int false "Number of results to skip" default(0)
-// @Success 200 {object} object{properties=[]models.Property,total=int,limit=int,offset=int} "List of properties"
+// @Param cursor query string false "next_cursor of the previous page, with the same sort and filters"
+// @Param offset query int false "Number of results to skip; ignored with cursor" default(0)
+// @Success 200 {object} object{properties=[]models.Property,total=int,next_cursor=string,filters=models.PropertySearchFilters} "List of properties"
+// @Failure 400 {object} object{error=string} "Invalid search query, location, sort or cursor"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /properties [get]
 func (h *PropertyHandler) GetPublicProperties(c *gin.Context) {
-	// Parse query parameters for filtering
+	filters, ok := bindSearchFilters(c)
+	if !ok {
+		return
+	}
+
+	// Sorting and pagination
+	if !bindSortAndPage(c, filters) {
+		return
+	}
+
+	// Only show published properties for public listings
+	published := models.PropertyStatusPublished
+	filters.Status = &published
+	filters.HideSuspendedAgents = true
+
+	page, err := h.propertyRepo.Search(filters)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor; cursors only work with the sort they were issued for",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search properties",
+		})
+		return
+	}
+	properties := page.Properties
+
+	// Get images for each property
+	for _, property := range properties {
+		images, err := h.propertyImageRepo.GetByPropertyID(property.ID)
+		if err == nil {
+			property.Images = images
+		}
+	}
+
+	// Attach the ratings from tenant reviews
+	propertyIDs := make([]uuid.UUID, len(properties))
+	for i, property := range properties {
+		propertyIDs[i] = property.ID
+	}
+	if ratings, err := h.reviewRepo.GetSummaries(models.ReviewTargetProperty, propertyIDs); err == nil {
+		for _, property := range properties {
+			property.Rating = ratings[property.ID]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"properties":  properties,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+		"filters":     filters,
+	})
+}
+
+// clusterListingThreshold is the most listings a map cluster holds before its
+// listings are left out
+const clusterListingThreshold = 10
+
+// GetPropertyClusters handles grouping the listings on a map into clusters
+// @Summary Get map clusters of property listings
+// @Description Group the published properties inside a map bounding box into geohash cells sized for the zoom level. Each cluster has its count, centroid and rent range; clusters of at most 10 listings also list them. Takes the same filters as the property search.
+// @Tags Properties
+// @Produce json
+// @Param min_lat query number true "South edge of the map bounding box"
+// @Param min_lng query number true "West edge of the map bounding box"
+// @Param max_lat query number true "North edge of the map bounding box"
+// @Param max_lng query number true "East edge of the map bounding box"
+// @Param zoom query int true "Map zoom level, 0 to 22"
+// @Param q query string false "Full-text search over title, description, location details and county and sub-county names"
+// @Param county_id query int false "Filter by county ID"
+// @Param property_type query string false "Filter by property type"
+// @Param min_rent query number false "Minimum rent amount"
+// @Param max_rent query number false "Maximum rent amount"
+// @Param min_bedrooms query int false "Minimum number of bedrooms"
+// @Param amenities query string false "Comma-separated amenity keys from GET /amenities"
+// @Success 200 {object} object{clusters=[]models.PropertyCluster,precision=int,zoom=int} "Clusters"
+// @Failure 400 {object} object{error=string} "Invalid bounding box, zoom or filters"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /properties/clusters [get]
+func (h *PropertyHandler) GetPropertyClusters(c *gin.Context) {
+	filters, ok := bindSearchFilters(c)
+	if !ok {
+		return
+	}
+	if filters.Bounds == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A bounding box is required: min_lat, min_lng, max_lat and max_lng",
+		})
+		return
+	}
+
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 || zoom > 22 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid zoom, expected a whole number from 0 to 22",
+		})
+		return
+	}
+
+	// Only show published properties, as in public listings
+	published := models.PropertyStatusPublished
+	filters.Status = &published
+	filters.HideSuspendedAgents = true
+
+	precision := geohashPrecision(zoom)
+	clusters, err := h.propertyRepo.Cluster(filters, precision, clusterListingThreshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cluster properties",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"clusters":  clusters,
+		"zoom":      zoom,
+		"precision": precision,
+	})
+}
+
+// geohashPrecision returns the geohash length whose cells are about an eighth of
+// a map tile wide at the zoom level: 1 at zoom 0, 5 at zoom 10 and 9 at zoom 20
+func geohashPrecision(zoom int) int {
+	precision := (2*zoom + 6) / 5
+	if precision < 1 {
+		return 1
+	}
+	return precision
+}
+
+// bindSearchFilters parses the query parameters of a public property search,
+// writing an error response if they are invalid
+func bindSearchFilters(c *gin.Context) (*models.PropertySearchFilters, bool) {
 	filters := &models.PropertySearchFilters{}
 
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		if len(q) > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Search query must be at most 200 characters",
+			})
+			return nil, false
+		}
+		filters.Query = q
+	}
+
 	if countyIDStr := c.Query("county_id"); countyIDStr != "" {
 		if countyID, err := strconv.Atoi(countyIDStr); err == nil {
 			filters.CountyID = &countyID
@@ -241,58 +452,192 @@ func (h *PropertyHandler) GetPublicProperties(c *gin.Context) {
 		}
 	}
 
-	// Pagination
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filters.Limit = limit
+	if amenitiesStr := c.Query("amenities"); amenitiesStr != "" {
+		for _, key := range strings.Split(amenitiesStr, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if _, ok := models.LookupAmenity(key); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Unknown amenity " + strconv.Quote(key) + ", see GET /amenities",
+				})
+				return nil, false
+			}
+			filters.Amenities = append(filters.Amenities, key)
 		}
 	}
-	if filters.Limit == 0 {
-		filters.Limit = 20 // Default limit
+
+	// Geo search and sorting
+	if !bindGeoFilters(c, filters) {
+		return nil, false
 	}
+	return filters, true
+}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filters.Offset = offset
+// maxSearchRadiusKm bounds radius searches
+const maxSearchRadiusKm = 500
+
+// bindGeoFilters parses the radius search, bounding box and sort of a property
+// search into filters, writing an error response if they are invalid
+func bindGeoFilters(c *gin.Context, filters *models.PropertySearchFilters) bool {
+	parseCoordinate := func(name string, limit float64) (*float64, bool) {
+		str := c.Query(name)
+		if str == "" {
+			return nil, true
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil || math.IsNaN(value) || value < -limit || value > limit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid %s, expected a number between %g and %g", name, -limit, limit),
+			})
+			return nil, false
 		}
+		return &value, true
 	}
 
-	// Only show available properties for public listings
-	isAvailable := true
-	filters.IsAvailable = &isAvailable
-
-	properties, err := h.propertyRepo.Search(filters)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to search properties",
+	lat, ok := parseCoordinate("lat", 90)
+	if !ok {
+		return false
+	}
+	lng, ok := parseCoordinate("lng", 180)
+	if !ok {
+		return false
+	}
+	if (lat == nil) != (lng == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "lat and lng must be given together",
 		})
-		return
+		return false
+	}
+	if lat != nil {
+		filters.Near = &models.GeoPoint{Lat: *lat, Lng: *lng}
 	}
 
-	// Get images for each property
-	for _, property := range properties {
-		images, err := h.propertyImageRepo.GetByPropertyID(property.ID)
-		if err == nil {
-			property.Images = images
+	if radiusStr := c.Query("radius_km"); radiusStr != "" {
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || !(radius > 0 && radius <= maxSearchRadiusKm) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid radius_km, expected more than 0 and at most %d", maxSearchRadiusKm),
+			})
+			return false
 		}
+		if filters.Near == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "radius_km requires lat and lng",
+			})
+			return false
+		}
+		filters.RadiusKm = &radius
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"properties": properties,
-		"filters":    filters,
-	})
+	var edges [4]*float64
+	given := 0
+	for i, edge := range []struct {
+		name  string
+		limit float64
+	}{{"min_lat", 90}, {"min_lng", 180}, {"max_lat", 90}, {"max_lng", 180}} {
+		if edges[i], ok = parseCoordinate(edge.name, edge.limit); !ok {
+			return false
+		}
+		if edges[i] != nil {
+			given++
+		}
+	}
+	if given > 0 {
+		if given < len(edges) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "A bounding box needs min_lat, min_lng, max_lat and max_lng",
+			})
+			return false
+		}
+		bounds := &models.GeoBounds{MinLat: *edges[0], MinLng: *edges[1], MaxLat: *edges[2], MaxLng: *edges[3]}
+		if bounds.MinLat > bounds.MaxLat || bounds.MinLng > bounds.MaxLng {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid bounding box, min_lat and min_lng must not exceed max_lat and max_lng",
+			})
+			return false
+		}
+		filters.Bounds = bounds
+	}
+
+	return true
+}
+
+// propertySorts lists the sort query values
+var propertySorts = []models.PropertySort{
+	models.PropertySortRelevance,
+	models.PropertySortNewest,
+	models.PropertySortPriceAsc,
+	models.PropertySortPriceDesc,
+	models.PropertySortBedroomsAsc,
+	models.PropertySortBedroomsDesc,
+	models.PropertySortDistance,
+}
+
+// bindSortAndPage parses the sort and pagination of a property listing into
+// filters, writing an error response if they are invalid
+func bindSortAndPage(c *gin.Context, filters *models.PropertySearchFilters) bool {
+	if sortStr := c.Query("sort"); sortStr != "" {
+		sort := models.PropertySort(sortStr)
+		valid := false
+		for _, known := range propertySorts {
+			valid = valid || sort == known
+		}
+		if !valid {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid sort, expected one of relevance, newest, price_asc, price_desc, bedrooms_asc, bedrooms_desc or distance",
+			})
+			return false
+		}
+		if sort == models.PropertySortRelevance && filters.Query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "sort=relevance requires q",
+			})
+			return false
+		}
+		if sort == models.PropertySortDistance && filters.Near == nil && filters.Bounds == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "sort=distance requires lat and lng or a bounding box",
+			})
+			return false
+		}
+		filters.Sort = sort
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+	if filters.Limit == 0 {
+		filters.Limit = 20 // Default limit
+	}
+
+	// A cursor takes precedence over offset
+	filters.Cursor = c.Query("cursor")
+	if offsetStr := c.Query("offset"); offsetStr != "" && filters.Cursor == "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			filters.Offset = offset
+		}
+	}
+	return true
 }
 
 // GetMyProperties handles getting properties for the authenticated agent
 // @Summary Get my properties
-// @Description Get all properties managed by the authenticated agent
+// @Description Get all properties managed by the authenticated agent, in every status
 // @Tags Properties
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param status query string false "Only properties in this status" Enums(draft,pending_review,published,let,archived)
+// @Param sort query string false "Sort order" Enums(newest,price_asc,price_desc,bedrooms_asc,bedrooms_desc)
 // @Param limit query int false "Number of results per page" default(20)
-// @Param offset query int false "Number of results to skip" default(0)
-// @Success 200 {object} object{properties=[]models.Property} "List of landlord's properties"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param offset query int false "Number of results to skip; ignored with cursor" default(0)
+// @Success 200 {object} object{properties=[]models.Property,total=int,next_cursor=string} "List of the agent's properties"
+// @Failure 400 {object} object{error=string} "Invalid status, sort or cursor"
 // @Failure 401 {object} object{error=string} "Unauthorized"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /my-properties [get]
@@ -313,29 +658,37 @@ func (h *PropertyHandler) GetMyProperties(c *gin.Context) {
 		return
 	}
 
-	// Pagination
-	limit := 20
-	offset := 0
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	filters := &models.PropertySearchFilters{AgentID: &agentID}
+	if statusStr := c.Query("status"); statusStr != "" {
+		status := models.PropertyStatus(statusStr)
+		if !status.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid status, expected one of draft, pending_review, published, let or archived",
+			})
+			return
 		}
+		filters.Status = &status
 	}
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	// Sorting and pagination
+	if !bindSortAndPage(c, filters) {
+		return
 	}
 
-	properties, err := h.propertyRepo.GetByAgentID(agentID, limit, offset)
+	page, err := h.propertyRepo.Search(filters)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor; cursors only work with the sort they were issued for",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get properties",
 		})
 		return
 	}
+	properties := page.Properties
 
 	// Get images for each property
 	for _, property := range properties {
@@ -346,13 +699,15 @@ func (h *PropertyHandler) GetMyProperties(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"properties": properties,
+		"properties":  properties,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
 	})
 }
 
-// UpdateProperty handles property updates (landlord only)
+// UpdateProperty handles property updates (agent only)
 // @Summary Update property
-// @Description Update property information (landlord only)
+// @Description Update property information (agent only). is_available moves a published listing to let and a let one back to published. If LISTING_EDITS_REQUIRE_REVIEW is set, changing the title, description, rent, deposit, rooms or location of a published listing sends it back for review, and relisting a let listing whose key fields or photos changed while let sends it for review.
 // @Tags Properties
 // @Accept json
 // @Produce json
@@ -364,6 +719,7 @@ func (h *PropertyHandler) GetMyProperties(c *gin.Context) {
 // @Failure 401 {object} object{error=string} "Unauthorized"
 // @Failure 403 {object} object{error=string} "You can only update your own properties"
 // @Failure 404 {object} object{error=string} "Property not found"
+// @Failure 409 {object} object{error=string} "is_available cannot change in the listing's status, or the status changed meanwhile"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /properties/{id} [put]
 func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
@@ -407,8 +763,8 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns the property
-	if property.AgentID != agentID {
+	// Check if user may manage the property
+	if !canManageProperty(c, property, agentID) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "You can only update your own properties",
 		})
@@ -424,6 +780,40 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 		return
 	}
 
+	if req.Amenities != nil {
+		if err := req.Amenities.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid amenities",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.OwnerID != nil && !h.checkOwner(c, *req.OwnerID) {
+		return
+	}
+
+	// is_available lets a published listing or relists a let one
+	var availability models.PropertyStatus
+	if req.IsAvailable != nil && *req.IsAvailable != property.IsAvailable {
+		availability = models.PropertyStatusLet
+		if *req.IsAvailable {
+			availability = models.PropertyStatusPublished
+		}
+		if !property.Status.CanAgentMoveTo(availability) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "is_available only moves a published listing to let and back; use POST /properties/{id}/status",
+			})
+			return
+		}
+	}
+	reviewedFieldsChanged := req.ChangesReviewedFields(property)
+
+	before := models.AuditSnapshot(property, propertyAuditOmit...)
+	loadedStatus := property.Status
+	loadedEditedWhileLet := property.EditedWhileLet
+
 	// Update fields if provided
 	if req.Title != nil {
 		property.Title = *req.Title
@@ -467,29 +857,65 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 	if req.IsFurnished != nil {
 		property.IsFurnished = *req.IsFurnished
 	}
-	if req.IsAvailable != nil {
-		property.IsAvailable = *req.IsAvailable
-	}
 	if req.AvailabilityDate != nil {
 		property.AvailabilityDate = req.AvailabilityDate
 	}
+	if req.OwnerID != nil {
+		property.OwnerID = req.OwnerID
+	}
+	if availability == models.PropertyStatusPublished {
+		availability = h.relistStatus(c, property, reviewedFieldsChanged)
+	}
+	if availability != "" {
+		property.SetStatus(availability)
+	}
+	sentForReview := availability == models.PropertyStatusPendingReview ||
+		(reviewedFieldsChanged && h.needsReview(c, property))
+	if sentForReview {
+		property.SetStatus(models.PropertyStatusPendingReview)
+	}
+	if reviewedFieldsChanged && h.notesEditWhileLet(c, property) {
+		property.EditedWhileLet = true
+	}
 
-	if err := h.propertyRepo.Update(property); err != nil {
+	// Status changes only apply if no one changed the status meanwhile
+	if property.Status != loadedStatus || property.EditedWhileLet != loadedEditedWhileLet {
+		err = h.propertyRepo.UpdateWithStatus(property, loadedStatus)
+	} else {
+		err = h.propertyRepo.Update(property)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrPropertyStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "The property's status changed meanwhile; reload it and try again",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update property",
 		})
 		return
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyUpdate,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, before, models.AuditSnapshot(property, propertyAuditOmit...))
+
+	message := "Property updated successfully"
+	if sentForReview {
+		message = "Property updated and sent back for review"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Property updated successfully",
+		"message":  message,
 		"property": property,
 	})
 }
 
-// DeleteProperty handles property deletion (landlord only)
+// DeleteProperty handles property deletion (agent only)
 // @Summary Delete property
-// @Description Delete a property (landlord only)
+// @Description Delete a property (agent only)
 // @Tags Properties
 // @Accept json
 // @Produce json
@@ -543,8 +969,8 @@ func (h *PropertyHandler) DeleteProperty(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns the property
-	if property.AgentID != agentID {
+	// Check if user may manage the property
+	if !canManageProperty(c, property, agentID) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "You can only delete your own properties",
 		})
@@ -558,6 +984,12 @@ func (h *PropertyHandler) DeleteProperty(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyDelete,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, models.AuditSnapshot(property, propertyAuditOmit...), nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Property deleted successfully",
 	})
@@ -565,7 +997,7 @@ func (h *PropertyHandler) DeleteProperty(c *gin.Context) {
 
 // AddPropertyImage handles adding images to a property
 // @Summary Add property image
-// @Description Add an image to a property (landlord only)
+// @Description Add an image to a property (agent only). If LISTING_EDITS_REQUIRE_REVIEW is set, a published listing goes back for review.
 // @Tags Properties
 // @Accept multipart/form-data
 // @Produce json
@@ -608,7 +1040,7 @@ func (h *PropertyHandler) AddPropertyImage(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns the property
+	// Check if user may manage the property
 	property, err := h.propertyRepo.GetByID(propertyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -623,7 +1055,7 @@ func (h *PropertyHandler) AddPropertyImage(c *gin.Context) {
 		return
 	}
 
-	if property.AgentID != agentID {
+	if !canManageProperty(c, property, agentID) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "You can only add images to your own properties",
 		})
@@ -725,15 +1157,19 @@ func (h *PropertyHandler) AddPropertyImage(c *gin.Context) {
 		return
 	}
 
+	message := "Image added successfully"
+	if h.sendBackForReview(c, property) {
+		message = "Image added; the property was sent back for review"
+	}
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Image added successfully",
+		"message": message,
 		"image":   image,
 	})
 }
 
 // DeletePropertyImage handles deleting a property image
 // @Summary Delete property image
-// @Description Delete an image from a property (landlord only)
+// @Description Delete an image from a property (agent only). If LISTING_EDITS_REQUIRE_REVIEW is set, a published listing goes back for review.
 // @Tags Properties
 // @Accept json
 // @Produce json
@@ -782,7 +1218,7 @@ func (h *PropertyHandler) DeletePropertyImage(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns the property
+	// Check if user may manage the property
 	property, err := h.propertyRepo.GetByID(propertyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -797,7 +1233,7 @@ func (h *PropertyHandler) DeletePropertyImage(c *gin.Context) {
 		return
 	}
 
-	if property.AgentID != agentID {
+	if !canManageProperty(c, property, agentID) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "You can only delete images from your own properties",
 		})
@@ -841,8 +1277,49 @@ func (h *PropertyHandler) DeletePropertyImage(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyImageDelete,
+		TargetType: models.AuditTargetPropertyImage,
+		TargetID:   image.ID.String(),
+	}, models.AuditSnapshot(image), nil)
+
+	message := "Image deleted successfully"
+	if h.sendBackForReview(c, property) {
+		message = "Image deleted; the property was sent back for review"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Image deleted successfully",
+		"message": message,
 	})
 }
 
+// canManageProperty checks if the user is the property's agent, may manage any property,
+// or manages the agency whose portfolio the property belongs to
+func canManageProperty(c *gin.Context, property *models.Property, userID uuid.UUID) bool {
+	if property.AgentID == userID || middleware.HasPermission(c, models.PermPropertyWriteAny) {
+		return true
+	}
+	if property.AgencyID == nil || !middleware.HasPermission(c, models.PermAgencyManage) {
+		return false
+	}
+	user, ok := middleware.GetCurrentUser(c)
+	return ok && user.AgencyID != nil && *user.AgencyID == *property.AgencyID
+}
+
+// checkOwner checks that the owner named in a request is an active landlord,
+// writing an error response if not
+func (h *PropertyHandler) checkOwner(c *gin.Context, ownerID uuid.UUID) bool {
+	owner, err := h.userRepo.GetByID(ownerID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check owner",
+		})
+		return false
+	}
+	if err != nil || owner.UserType != models.UserTypeLandlord {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Owner must be an active landlord account",
+		})
+		return false
+	}
+	return true
+}
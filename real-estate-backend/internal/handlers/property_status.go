@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"real-estate-backend/internal/middleware"
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UpdatePropertyStatus moves a listing through its lifecycle (agent only)
+// @Summary Change a property's status
+// @Description Submit a draft for review, withdraw it, mark a published listing as let, relist it or archive it. Agents cannot publish a listing themselves; moderators approve it from the review queue. Allowed changes: draft to pending_review or archived; pending_review to draft or archived; published to let, archived or draft; let to published or archived; archived to draft. If LISTING_EDITS_REQUIRE_REVIEW is set and key fields or photos changed while the listing was let, relisting it sends it to pending_review instead.
+// @Tags Properties
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Property ID" Format(uuid)
+// @Param status body models.UpdatePropertyStatusRequest true "New status"
+// @Success 200 {object} object{message=string,property=models.Property} "Status changed"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "You can only change the status of your own properties"
+// @Failure 404 {object} object{error=string} "Property not found"
+// @Failure 409 {object} object{error=string,allowed=[]string} "The listing cannot move to the status"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /properties/{id}/status [post]
+func (h *PropertyHandler) UpdatePropertyStatus(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	property, ok := h.loadProperty(c)
+	if !ok {
+		return
+	}
+
+	if !canManageProperty(c, property, userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only change the status of your own properties",
+		})
+		return
+	}
+
+	var req models.UpdatePropertyStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	from := property.Status
+	if !from.CanAgentMoveTo(req.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "A " + string(from) + " listing cannot move to " + string(req.Status),
+			"allowed": from.AgentTransitions(),
+		})
+		return
+	}
+
+	to := req.Status
+	if from == models.PropertyStatusLet && to == models.PropertyStatusPublished {
+		to = h.relistStatus(c, property, false)
+	}
+	property.SetStatus(to)
+	if !h.saveStatus(c, property, from) {
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyStatus,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, map[string]interface{}{"status": from}, map[string]interface{}{"status": property.Status})
+
+	message := "Property status changed"
+	if to != req.Status {
+		message = "Property sent for review, as it was edited while let"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"property": property,
+	})
+}
+
+// GetPendingProperties lists listings waiting for moderation (requires property:moderate)
+// @Summary List properties pending review
+// @Description Get the listings agents submitted for review, longest waiting first
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{properties=[]models.Property,total=int,limit=int,offset=int} "Properties pending review"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/properties/pending [get]
+func (h *PropertyHandler) GetPendingProperties(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	properties, total, err := h.propertyRepo.GetPendingReview(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get properties pending review",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"properties": properties,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// ApproveProperty publishes a listing waiting for review (requires property:moderate)
+// @Summary Approve a property
+// @Description Publish a listing from the review queue, clearing any earlier rejection reason
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Property ID" Format(uuid)
+// @Success 200 {object} object{message=string,property=models.Property} "Property published"
+// @Failure 400 {object} object{error=string} "Invalid property ID"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Property not found"
+// @Failure 409 {object} object{error=string} "Property is not pending review"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/properties/{id}/approve [post]
+func (h *PropertyHandler) ApproveProperty(c *gin.Context) {
+	property, ok := h.loadProperty(c)
+	if !ok {
+		return
+	}
+
+	from := property.Status
+	if from != models.PropertyStatusPendingReview {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only listings pending review can be approved",
+		})
+		return
+	}
+
+	moderatorID, _ := getUserID(c)
+	property.Approve(moderatorID)
+	if !h.saveStatus(c, property, from) {
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyApprove,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, map[string]interface{}{"status": from}, map[string]interface{}{"status": property.Status})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Property published",
+		"property": property,
+	})
+}
+
+// RejectProperty sends a listing back to its agent (requires property:moderate)
+// @Summary Reject a property
+// @Description Return a listing pending review, or take down a published one, to draft with a reason the agent sees as rejection_reason
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Property ID" Format(uuid)
+// @Param rejection body models.RejectPropertyRequest true "Rejection reason"
+// @Success 200 {object} object{message=string,property=models.Property} "Property rejected"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Property not found"
+// @Failure 409 {object} object{error=string} "Property is not pending review or published"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/properties/{id}/reject [post]
+func (h *PropertyHandler) RejectProperty(c *gin.Context) {
+	property, ok := h.loadProperty(c)
+	if !ok {
+		return
+	}
+
+	var req models.RejectPropertyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	from := property.Status
+	if !from.CanReject() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only listings pending review or published can be rejected",
+		})
+		return
+	}
+
+	moderatorID, _ := getUserID(c)
+	property.Reject(moderatorID, req.Reason)
+	if !h.saveStatus(c, property, from) {
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyReject,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, map[string]interface{}{"status": from}, map[string]interface{}{"status": property.Status, "reason": req.Reason})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Property rejected",
+		"property": property,
+	})
+}
+
+// moderatesEdits checks if edits of key fields or photos by the current user are
+// moderated: LISTING_EDITS_REQUIRE_REVIEW is set and the editor is not a moderator
+func (h *PropertyHandler) moderatesEdits(c *gin.Context) bool {
+	return h.editsNeedReview && !middleware.HasPermission(c, models.PermPropertyModerate)
+}
+
+// needsReview checks if an edit of key fields or photos sends the listing back to
+// the moderation queue: edits are moderated and the listing is published
+func (h *PropertyHandler) needsReview(c *gin.Context, property *models.Property) bool {
+	return property.Status == models.PropertyStatusPublished && h.moderatesEdits(c)
+}
+
+// notesEditWhileLet checks if an edit of key fields or photos must be recorded so
+// that relisting the listing goes through moderation, see relistStatus
+func (h *PropertyHandler) notesEditWhileLet(c *gin.Context, property *models.Property) bool {
+	return property.Status == models.PropertyStatusLet && !property.EditedWhileLet && h.moderatesEdits(c)
+}
+
+// relistStatus returns the status a let listing moves to when it is relisted:
+// pending_review if edits are moderated and key fields or photos changed while it
+// was let or change in this request, and published otherwise
+func (h *PropertyHandler) relistStatus(c *gin.Context, property *models.Property, edited bool) models.PropertyStatus {
+	if (property.EditedWhileLet || edited) && h.moderatesEdits(c) {
+		return models.PropertyStatusPendingReview
+	}
+	return models.PropertyStatusPublished
+}
+
+// sendBackForReview returns a published listing to the moderation queue after a
+// change of its photos, see needsReview, or records the change on a let listing,
+// see relistStatus. It reports whether the listing was sent back.
+func (h *PropertyHandler) sendBackForReview(c *gin.Context, property *models.Property) bool {
+	if h.notesEditWhileLet(c, property) {
+		property.EditedWhileLet = true
+		if err := h.propertyRepo.UpdateStatus(property, models.PropertyStatusLet); err != nil {
+			log.Printf("Failed to record the photo change of let property %s: %v", property.ID, err)
+		}
+		return false
+	}
+	if !h.needsReview(c, property) {
+		return false
+	}
+
+	property.SetStatus(models.PropertyStatusPendingReview)
+	if err := h.propertyRepo.UpdateStatus(property, models.PropertyStatusPublished); err != nil {
+		log.Printf("Failed to send property %s back for review: %v", property.ID, err)
+		return false
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditPropertyStatus,
+		TargetType: models.AuditTargetProperty,
+		TargetID:   property.ID.String(),
+	}, map[string]interface{}{"status": models.PropertyStatusPublished}, map[string]interface{}{"status": property.Status})
+	return true
+}
+
+// saveStatus saves a status change made from status from, writing an error
+// response if it fails
+func (h *PropertyHandler) saveStatus(c *gin.Context, property *models.Property, from models.PropertyStatus) bool {
+	if err := h.propertyRepo.UpdateStatus(property, from); err != nil {
+		if errors.Is(err, models.ErrPropertyStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "The property's status changed meanwhile; reload it and try again",
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to change property status",
+		})
+		return false
+	}
+	return true
+}
+
+// loadProperty loads the property named in the URL, writing an error response if it cannot
+func (h *PropertyHandler) loadProperty(c *gin.Context) (*models.Property, bool) {
+	propertyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid property ID",
+		})
+		return nil, false
+	}
+
+	property, err := h.propertyRepo.GetByID(propertyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Property not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get property",
+		})
+		return nil, false
+	}
+	return property, true
+}
@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewHandler handles tenant reviews of properties and agents
+type ReviewHandler struct {
+	reviewRepo   *models.ReviewRepository
+	propertyRepo *models.PropertyRepository
+	userRepo     *models.UserRepository
+	auditRepo    *models.AuditLogRepository
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler(reviewRepo *models.ReviewRepository, propertyRepo *models.PropertyRepository, userRepo *models.UserRepository, auditRepo *models.AuditLogRepository) *ReviewHandler {
+	return &ReviewHandler{
+		reviewRepo:   reviewRepo,
+		propertyRepo: propertyRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// PublicAgentProfile represents an agent as shown to the public
+type PublicAgentProfile struct {
+	ID              uuid.UUID             `json:"id"`
+	FirstName       string                `json:"first_name"`
+	LastName        string                `json:"last_name"`
+	ProfileImageURL *string               `json:"profile_image_url,omitempty"`
+	Agency          *models.Agency        `json:"agency,omitempty"`
+	ApprovedAt      *time.Time            `json:"approved_at,omitempty"`
+	Rating          *models.RatingSummary `json:"rating"`
+}
+
+// GetAgentProfile returns an agent's public profile with their rating
+// @Summary Get an agent's public profile
+// @Description Get an approved agent's name, photo, agency and aggregate rating from tenant reviews. Suspended agents are not found.
+// @Tags Reviews
+// @Produce json
+// @Param agentId path string true "Agent ID" Format(uuid)
+// @Success 200 {object} object{agent=handlers.PublicAgentProfile} "Agent profile"
+// @Failure 400 {object} object{error=string} "Invalid agent ID"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agents/{agentId} [get]
+func (h *ReviewHandler) GetAgentProfile(c *gin.Context) {
+	agent, ok := h.loadAgent(c)
+	if !ok {
+		return
+	}
+
+	rating, err := h.reviewRepo.GetSummary(models.ReviewTargetAgent, agent.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get rating",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"agent": &PublicAgentProfile{
+			ID:              agent.ID,
+			FirstName:       agent.FirstName,
+			LastName:        agent.LastName,
+			ProfileImageURL: agent.ProfileImageURL,
+			Agency:          agent.Agency,
+			ApprovedAt:      agent.ApprovedAt,
+			Rating:          rating,
+		},
+	})
+}
+
+// GetAgentReviews lists an agent's reviews
+// @Summary Get an agent's reviews
+// @Description Get the reviews tenants wrote about an agent, newest first, with the agent's replies
+// @Tags Reviews
+// @Produce json
+// @Param agentId path string true "Agent ID" Format(uuid)
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{reviews=[]models.ReviewResponse,rating=models.RatingSummary,total=int,limit=int,offset=int} "Reviews"
+// @Failure 400 {object} object{error=string} "Invalid agent ID"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agents/{agentId}/reviews [get]
+func (h *ReviewHandler) GetAgentReviews(c *gin.Context) {
+	agentID, err := uuid.Parse(c.Param("agentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid agent ID",
+		})
+		return
+	}
+	h.listReviews(c, models.ReviewTargetAgent, agentID)
+}
+
+// GetPropertyReviews lists a property's reviews
+// @Summary Get a property's reviews
+// @Description Get the reviews tenants wrote about a property, newest first, with the agent's replies
+// @Tags Reviews
+// @Produce json
+// @Param id path string true "Property ID" Format(uuid)
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{reviews=[]models.ReviewResponse,rating=models.RatingSummary,total=int,limit=int,offset=int} "Reviews"
+// @Failure 400 {object} object{error=string} "Invalid property ID"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /properties/{id}/reviews [get]
+func (h *ReviewHandler) GetPropertyReviews(c *gin.Context) {
+	propertyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid property ID",
+		})
+		return
+	}
+	h.listReviews(c, models.ReviewTargetProperty, propertyID)
+}
+
+// CreatePropertyReview reviews a property the tenant rented (requires review:write)
+// @Summary Review a property
+// @Description Rate a property the authenticated tenant has held a lease on. Scores must include condition, value and neighbourhood, each from 1 to 5. Each tenant can review a property once.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Property ID" Format(uuid)
+// @Param review body models.CreateReviewRequest true "Review"
+// @Success 201 {object} object{message=string,review=models.ReviewResponse} "Review created"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "No lease on the property"
+// @Failure 404 {object} object{error=string} "Property not found"
+// @Failure 409 {object} object{error=string} "Property already reviewed"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /properties/{id}/reviews [post]
+func (h *ReviewHandler) CreatePropertyReview(c *gin.Context) {
+	propertyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid property ID",
+		})
+		return
+	}
+
+	property, err := h.propertyRepo.GetByID(propertyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Property not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get property",
+		})
+		return
+	}
+
+	h.createReview(c, models.ReviewTargetProperty, property.ID, property.AgentID, h.reviewRepo.HasLeaseOnProperty)
+}
+
+// CreateAgentReview reviews an agent the tenant dealt with (requires review:write)
+// @Summary Review an agent
+// @Description Rate an agent who manages a property the authenticated tenant has held a lease on. Scores must include communication, responsiveness and professionalism, each from 1 to 5. Each tenant can review an agent once.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param agentId path string true "Agent ID" Format(uuid)
+// @Param review body models.CreateReviewRequest true "Review"
+// @Success 201 {object} object{message=string,review=models.ReviewResponse} "Review created"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "No lease with the agent"
+// @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string} "Agent already reviewed"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /agents/{agentId}/reviews [post]
+func (h *ReviewHandler) CreateAgentReview(c *gin.Context) {
+	agent, ok := h.loadAgent(c)
+	if !ok {
+		return
+	}
+
+	h.createReview(c, models.ReviewTargetAgent, agent.ID, agent.ID, h.reviewRepo.HasLeaseWithAgent)
+}
+
+// ReplyToReview posts the agent's reply to a review
+// @Summary Reply to a review
+// @Description Post a public reply to a review of yourself or of a property you manage. Each review can have one reply.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Review ID" Format(uuid)
+// @Param reply body models.ReplyToReviewRequest true "Reply"
+// @Success 200 {object} object{message=string,review=models.ReviewResponse} "Reply posted"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Not the agent of the review"
+// @Failure 404 {object} object{error=string} "Review not found"
+// @Failure 409 {object} object{error=string} "Review already has a reply"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /reviews/{id}/reply [post]
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	review, ok := h.loadReview(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := getUserID(c)
+	if review.AgentID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the agent of the review can reply",
+		})
+		return
+	}
+
+	var req models.ReplyToReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.reviewRepo.SetReply(review, req.Reply); err != nil {
+		if errors.Is(err, models.ErrReviewReplied) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "You have already replied to this review",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to post reply",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reply posted successfully",
+		"review":  review.ToResponse(),
+	})
+}
+
+// ReportReview reports a review for moderation
+// @Summary Report a review
+// @Description Report an inappropriate review. It stays visible until a moderator keeps or removes it.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Review ID" Format(uuid)
+// @Param report body models.ReportReviewRequest true "Reason for the report"
+// @Success 200 {object} object{message=string} "Review reported"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 404 {object} object{error=string} "Review not found"
+// @Failure 409 {object} object{error=string} "Review already reported"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /reviews/{id}/report [post]
+func (h *ReviewHandler) ReportReview(c *gin.Context) {
+	review, ok := h.loadReview(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReportReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	reporterID, _ := getUserID(c)
+	if err := h.reviewRepo.Report(review.ID, reporterID, req.Reason); err != nil {
+		if errors.Is(err, models.ErrReviewReported) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "You have already reported this review",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to report review",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review reported successfully",
+	})
+}
+
+// GetReportedReviews lists reviews waiting for moderation (requires review:moderate)
+// @Summary List reported reviews
+// @Description Get reported reviews with their open reports, most reported first
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param limit query int false "Number of results per page" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} object{reviews=[]models.ModerationReview,total=int,limit=int,offset=int} "Reported reviews"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/reviews/reported [get]
+func (h *ReviewHandler) GetReportedReviews(c *gin.Context) {
+	limit, offset := bindReviewPage(c)
+
+	reviews, total, err := h.reviewRepo.GetFlagged(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get reported reviews",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// ModerateReview keeps or removes a review (requires review:moderate)
+// @Summary Moderate a review
+// @Description Keep a review, or remove it so it is hidden and left out of ratings. Open reports on the review are resolved.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Review ID" Format(uuid)
+// @Param decision body models.ModerateReviewRequest true "Moderation decision"
+// @Success 200 {object} object{message=string} "Review moderated"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 404 {object} object{error=string} "Review not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/reviews/{id}/moderate [post]
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	review, ok := h.loadReview(c)
+	if !ok {
+		return
+	}
+
+	var req models.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	before := review.Status
+	moderatorID, _ := getUserID(c)
+	if err := h.reviewRepo.Moderate(review, moderatorID, req.Action == "keep", req.Notes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to moderate review",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditReviewModerate,
+		TargetType: models.AuditTargetReview,
+		TargetID:   review.ID.String(),
+	}, map[string]interface{}{"status": before}, map[string]interface{}{"status": review.Status, "notes": req.Notes})
+
+	message := "Review kept"
+	if review.Status == models.ReviewStatusRemoved {
+		message = "Review removed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// createReview checks that the tenant may review the target and saves the review.
+// hasLease reports whether the tenant has held a lease on the target.
+func (h *ReviewHandler) createReview(c *gin.Context, targetType models.ReviewTargetType, targetID, agentID uuid.UUID, hasLease func(tenantID, targetID uuid.UUID) (bool, error)) {
+	var req models.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(targetType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid scores",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenant, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	leased, err := hasLease(tenant.ID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check leases",
+		})
+		return
+	}
+	if !leased {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only tenants who have held a lease with this " + string(targetType) + " can review it",
+		})
+		return
+	}
+
+	review := &models.Review{
+		TargetType: targetType,
+		TargetID:   targetID,
+		TenantID:   tenant.ID,
+		AgentID:    agentID,
+		Rating:     req.Rating,
+		Scores:     req.Scores,
+		Comment:    req.Comment,
+		Status:     models.ReviewStatusPublished,
+	}
+	if err := h.reviewRepo.Create(review); err != nil {
+		if errors.Is(err, models.ErrReviewExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "You have already reviewed this " + string(targetType),
+			})
+			return
+		}
+		log.Printf("Failed to create %s review: %v", targetType, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create review",
+		})
+		return
+	}
+	review.Tenant = tenant
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Review created successfully",
+		"review":  review.ToResponse(),
+	})
+}
+
+// listReviews writes a page of the target's visible reviews
+func (h *ReviewHandler) listReviews(c *gin.Context, targetType models.ReviewTargetType, targetID uuid.UUID) {
+	limit, offset := bindReviewPage(c)
+
+	reviews, total, err := h.reviewRepo.GetForTarget(targetType, targetID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get reviews",
+		})
+		return
+	}
+
+	rating, err := h.reviewRepo.GetSummary(targetType, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get rating",
+		})
+		return
+	}
+
+	responses := make([]*models.ReviewResponse, len(reviews))
+	for i := range reviews {
+		responses[i] = reviews[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": responses,
+		"rating":  rating,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// loadAgent loads the approved agent named in the URL, writing an error response if it cannot
+func (h *ReviewHandler) loadAgent(c *gin.Context) (*models.User, bool) {
+	agentID, err := uuid.Parse(c.Param("agentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid agent ID",
+		})
+		return nil, false
+	}
+
+	agent, err := h.userRepo.GetApprovedAgent(agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agent not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get agent",
+		})
+		return nil, false
+	}
+	return agent, true
+}
+
+// loadReview loads the review named in the URL, writing an error response if it cannot
+func (h *ReviewHandler) loadReview(c *gin.Context) (*models.Review, bool) {
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return nil, false
+	}
+
+	review, err := h.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Review not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get review",
+		})
+		return nil, false
+	}
+	return review, true
+}
+
+// bindReviewPage parses the limit and offset of a review listing
+func bindReviewPage(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
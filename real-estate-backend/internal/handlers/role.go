@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"real-estate-backend/internal/middleware"
+	"real-estate-backend/internal/models"
+)
+
+// RoleHandler handles role and permission management
+type RoleHandler struct {
+	userRepo      *models.UserRepository
+	userRoleRepo  *models.UserRoleRepository
+	twoFactorRepo *models.TwoFactorRepository
+	auditRepo     *models.AuditLogRepository
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(userRepo *models.UserRepository, userRoleRepo *models.UserRoleRepository, twoFactorRepo *models.TwoFactorRepository, auditRepo *models.AuditLogRepository) *RoleHandler {
+	return &RoleHandler{
+		userRepo:      userRepo,
+		userRoleRepo:  userRoleRepo,
+		twoFactorRepo: twoFactorRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// RoleResponse describes a role and the permissions it grants
+type RoleResponse struct {
+	Role        models.Role         `json:"role"`
+	Permissions []models.Permission `json:"permissions"`
+	Assignable  bool                `json:"assignable"`
+}
+
+// GetMyPermissions returns the authenticated user's roles and permissions
+// @Summary Get my permissions
+// @Description Get the roles and permissions of the authenticated user
+// @Tags Users
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{roles=[]string,permissions=[]string} "Roles and permissions"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Router /auth/permissions [get]
+func (h *RoleHandler) GetMyPermissions(c *gin.Context) {
+	roles := middleware.GetRoles(c)
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": models.PermissionsForRoles(roles),
+	})
+}
+
+// GetRoles lists every role and its permissions (requires role:assign)
+// @Summary List roles
+// @Description Get every role, the permissions it grants and whether it can be assigned
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{roles=[]handlers.RoleResponse} "Roles"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Router /admin/roles [get]
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	// Every defined role, in a stable order
+	roles := make([]models.Role, 0, len(models.RolePermissions))
+	for role := range models.RolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+
+	responses := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		responses = append(responses, RoleResponse{
+			Role:        role,
+			Permissions: models.PermissionsForRoles([]models.Role{role}),
+			Assignable:  role.IsAssignable(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles": responses,
+	})
+}
+
+// GetUserRoles lists a user's effective roles and permissions (requires role:assign)
+// @Summary Get a user's roles
+// @Description Get the base role, assigned roles and effective permissions of a user
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID" Format(uuid)
+// @Success 200 {object} object{roles=[]string,assigned=[]models.UserRole,permissions=[]string} "User roles"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/roles [get]
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	user, ok := h.getTargetUser(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.userRepo.GetRoles(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get roles",
+		})
+		return
+	}
+
+	assigned, err := h.userRoleRepo.GetByUserID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"assigned":    assigned,
+		"permissions": models.PermissionsForRoles(roles),
+	})
+}
+
+// AssignRole grants an additional role to a user (requires role:assign)
+// @Summary Assign a role
+// @Description Grant an assignable role to a user. It takes effect the next time the user's access token is refreshed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID" Format(uuid)
+// @Param role body models.AssignRoleRequest true "Role to assign"
+// @Success 200 {object} object{message=string} "Role assigned"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request or role"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/roles [post]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	var req models.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !req.Role.IsAssignable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Role cannot be assigned",
+		})
+		return
+	}
+
+	user, ok := h.getTargetUser(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := getUserID(c)
+	if err := h.userRoleRepo.Assign(user.ID, req.Role, adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to assign role",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditRoleAssign,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+		Changes:    models.AuditChanges{"role": {After: req.Role}},
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role assigned successfully",
+	})
+}
+
+// RemoveRole takes an assigned role away from a user (requires role:assign)
+// @Summary Remove a role
+// @Description Remove an assigned role from a user. The user's tokens are revoked so the change applies immediately.
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID" Format(uuid)
+// @Param role path string true "Role"
+// @Success 200 {object} object{message=string} "Role removed"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 404 {object} object{error=string} "User or role assignment not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/roles/{role} [delete]
+func (h *RoleHandler) RemoveRole(c *gin.Context) {
+	user, ok := h.getTargetUser(c)
+	if !ok {
+		return
+	}
+
+	role := models.Role(c.Param("role"))
+	if err := h.userRoleRepo.Remove(user.ID, role); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User does not have this role",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove role",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditRoleRemove,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+		Changes:    models.AuditChanges{"role": {Before: role}},
+	}, nil, nil)
+
+	// Tokens carry the user's roles, so revoke them to drop the role right away
+	if err := h.userRepo.RevokeAllTokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Role removed but failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role removed successfully",
+	})
+}
+
+// GetTwoFactorPolicies lists which roles require two-factor authentication (requires security:manage)
+// @Summary List two-factor policies
+// @Description Get the roles that have a two-factor policy and whether it is required
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{policies=[]models.TwoFactorPolicy} "Two-factor policies"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/2fa-policies [get]
+func (h *RoleHandler) GetTwoFactorPolicies(c *gin.Context) {
+	policies, err := h.twoFactorRepo.GetPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get two-factor policies",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+	})
+}
+
+// SetTwoFactorPolicy requires or stops requiring two-factor authentication for a role (requires security:manage)
+// @Summary Set a two-factor policy
+// @Description Require two-factor authentication for a role. Users holding the role cannot use admin, property or tenant routes until they log in with a second factor.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param policy body models.SetTwoFactorPolicyRequest true "Two-factor policy"
+// @Success 200 {object} object{message=string} "Policy updated"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request or role"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/2fa-policies [put]
+func (h *RoleHandler) SetTwoFactorPolicy(c *gin.Context) {
+	var req models.SetTwoFactorPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown role",
+		})
+		return
+	}
+
+	wasRequired, err := h.twoFactorRepo.IsRequired([]models.Role{req.Role})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update two-factor policy",
+		})
+		return
+	}
+
+	adminID, _ := getUserID(c)
+	if err := h.twoFactorRepo.SetPolicy(req.Role, req.Required, adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update two-factor policy",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditTwoFactorPolicySet,
+		TargetType: models.AuditTargetTwoFactorPolicy,
+		TargetID:   string(req.Role),
+	}, map[string]interface{}{"required": wasRequired}, map[string]interface{}{"required": req.Required})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor policy updated successfully",
+	})
+}
+
+// getTargetUser loads the user named by the userId path parameter, writing an
+// error response if it cannot
+func (h *RoleHandler) getTargetUser(c *gin.Context) (*models.User, bool) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"real-estate-backend/internal/models"
+	"real-estate-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TokenPair represents an access token and the refresh token that can renew it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// issueTokenPair starts a new session for the user and returns its tokens.
+// mfa records whether the user proved a second factor when logging in.
+func (h *UserHandler) issueTokenPair(c *gin.Context, user *models.User, mfa bool) (*TokenPair, error) {
+	refreshToken, tokenHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := newUserSession(c, user.ID, tokenHash, h.jwtManager.RefreshTokenTTL())
+	session.MFA = mfa
+	if err := h.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return h.buildTokenPair(user, refreshToken, mfa)
+}
+
+// buildTokenPair signs an access token for the user and pairs it with a refresh token
+func (h *UserHandler) buildTokenPair(user *models.User, refreshToken string, mfa bool) (*TokenPair, error) {
+	roles, err := h.userRepo.GetRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	roleNames := make([]string, len(roles))
+	for i, role := range roles {
+		roleNames[i] = string(role)
+	}
+
+	accessToken, err := h.jwtManager.GenerateToken(auth.TokenSubject{
+		UserID:       user.ID,
+		Email:        user.Email,
+		UserType:     string(user.UserType),
+		Roles:        roleNames,
+		TokenVersion: user.TokenVersion,
+		MFA:          mfa,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.jwtManager.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// newUserSession builds a session record for the current request
+func newUserSession(c *gin.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) *models.UserSession {
+	userAgent := c.Request.UserAgent()
+	ipAddress := c.ClientIP()
+	return &models.UserSession{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+		UserAgent: &userAgent,
+		IPAddress: &ipAddress,
+	}
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token. Reusing an already rotated refresh token revokes the whole session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} handlers.TokenPair "New token pair"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 401 {object} object{error=string} "Invalid, expired or reused refresh token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	session, err := h.sessionRepo.GetByTokenHash(auth.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid refresh token",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get session",
+		})
+		return
+	}
+
+	// A rotated token being presented again means it was copied; cut off the whole chain
+	if session.IsRevoked() {
+		if session.WasRotated() {
+			h.sessionRepo.RevokeFamily(session.FamilyID)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token reuse detected. Please log in again.",
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Session has been revoked",
+		})
+		return
+	}
+
+	if session.IsExpired() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Refresh token has expired",
+		})
+		return
+	}
+
+	// Reload the user so deactivated accounts cannot refresh
+	user, err := h.userRepo.GetByID(session.UserID)
+	if err != nil {
+		h.sessionRepo.RevokeFamily(session.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found or deactivated",
+		})
+		return
+	}
+
+	refreshToken, tokenHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	next := newUserSession(c, user.ID, tokenHash, h.jwtManager.RefreshTokenTTL())
+	if err := h.sessionRepo.Rotate(session, next); err != nil {
+		if errors.Is(err, models.ErrSessionAlreadyRotated) {
+			h.sessionRepo.RevokeFamily(session.FamilyID)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token reuse detected. Please log in again.",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to rotate session",
+		})
+		return
+	}
+
+	tokens, err := h.buildTokenPair(user, refreshToken, session.MFA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the session belonging to a refresh token
+// @Summary Log out
+// @Description Revoke the session belonging to the given refresh token. Access tokens already issued expire on their own shortly after.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} object{message=string} "Logged out"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	session, err := h.sessionRepo.GetByTokenHash(auth.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Logging out with an unknown token is a no-op
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Logged out successfully",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get session",
+		})
+		return
+	}
+
+	if err := h.sessionRepo.RevokeFamily(session.FamilyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll logs the current user out of every device
+// @Summary Log out everywhere
+// @Description Revoke every session and invalidate every access token of the authenticated user
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{message=string} "Logged out everywhere"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/logout-all [post]
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	if err := h.userRepo.RevokeAllTokens(userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all devices successfully",
+	})
+}
+
+// GetSessions lists the active sessions of the current user
+// @Summary List active sessions
+// @Description List the active refresh-token sessions of the authenticated user
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{sessions=[]models.UserSession} "Active sessions"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/sessions [get]
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	sessions, err := h.sessionRepo.GetActiveByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession revokes one of the current user's sessions
+// @Summary Revoke a session
+// @Description Revoke one of the authenticated user's sessions, e.g. for a lost device
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Session ID" Format(uuid)
+// @Success 200 {object} object{message=string} "Session revoked"
+// @Failure 400 {object} object{error=string} "Invalid session ID"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 404 {object} object{error=string} "Session not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid session ID",
+		})
+		return
+	}
+
+	sessions, err := h.sessionRepo.GetActiveByUserID(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			if err := h.sessionRepo.RevokeFamily(session.FamilyID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to revoke session",
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Session revoked successfully",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": "Session not found",
+	})
+}
+
+// RevokeUserSessions revokes every session of a user (requires session:revoke)
+// @Summary Revoke all sessions of a user
+// @Description Revoke every refresh-token session and access token of a user so they must log in again
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userId path string true "User ID" Format(uuid)
+// @Success 200 {object} object{message=string} "Sessions revoked"
+// @Failure 400 {object} object{error=string} "Invalid user ID"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{userId}/revoke-sessions [post]
+func (h *UserHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.userRepo.RevokeAllTokens(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke sessions",
+		})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditSessionsRevoke,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+	}, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All sessions revoked successfully",
+	})
+}
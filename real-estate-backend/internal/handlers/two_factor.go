@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"real-estate-backend/internal/middleware"
+	"real-estate-backend/internal/models"
+	"real-estate-backend/internal/services"
+	"real-estate-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// totpIssuer is the account issuer shown in authenticator apps
+const totpIssuer = "Real Estate Platform"
+
+// GetTwoFactorStatus returns the authenticated user's two-factor status
+// @Summary Get two-factor status
+// @Description Get whether two-factor authentication is enabled, required for the user's roles, and how many recovery codes remain
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{enabled=bool,required=bool,recovery_codes_remaining=int} "Two-factor status"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa [get]
+func (h *UserHandler) GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	required, err := h.twoFactorRepo.IsRequired(middleware.GetRoles(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check two-factor policy",
+		})
+		return
+	}
+
+	remaining, err := h.twoFactorRepo.CountUnusedRecoveryCodes(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  user.TwoFactorEnabled,
+		"required":                 required,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// SetupTwoFactor starts two-factor enrollment
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and an otpauth:// provisioning URI to show as a QR code. Two-factor authentication is not active until confirmed at /auth/2fa/enable.
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object{secret=string,otpauth_uri=string} "TOTP secret and provisioning URI"
+// @Failure 401 {object} object{error=string} "Unauthorized"
+// @Failure 409 {object} object{error=string} "Two-factor authentication already enabled"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa/setup [post]
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	user, ok := h.getCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate secret",
+		})
+		return
+	}
+
+	user.TOTPSecret = &secret
+	if err := h.userRepo.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save secret",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPProvisioningURI(secret, user.Email, totpIssuer),
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app
+// @Summary Enable two-factor authentication
+// @Description Confirm enrollment with a TOTP code. Returns single-use recovery codes, which are shown only once. All other sessions are signed out and a new session is started.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} object{message=string,recovery_codes=[]string,token=string,refresh_token=string,expires_in=int} "Two-factor authentication enabled"
+// @Failure 400 {object} object{error=string} "Invalid code or enrollment not started"
+// @Failure 409 {object} object{error=string} "Two-factor authentication already enabled"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa/enable [post]
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.getCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	if user.TOTPSecret == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Start enrollment at /auth/2fa/setup first",
+		})
+		return
+	}
+
+	valid, err := h.checkTOTP(user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid code",
+		})
+		return
+	}
+
+	user.TwoFactorEnabled = true
+	if err := h.userRepo.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to enable two-factor authentication",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorRepo.ReplaceRecoveryCodes(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate recovery codes",
+		})
+		return
+	}
+
+	// Sessions started with only a password are signed out; this one has proven both factors
+	if err := h.userRepo.RevokeAllTokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke sessions",
+		})
+		return
+	}
+
+	user, err = h.userRepo.GetByID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	tokens, err := h.issueTokenPair(c, user, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store your recovery codes somewhere safe.",
+		"recovery_codes": recoveryCodes,
+		"token":          tokens.AccessToken,
+		"refresh_token":  tokens.RefreshToken,
+		"token_type":     tokens.TokenType,
+		"expires_in":     tokens.ExpiresIn,
+	})
+}
+
+// DisableTwoFactor turns off two-factor authentication
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication with the account password and a TOTP or recovery code. Not allowed when a role of the user requires two-factor authentication.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.DisableTwoFactorRequest true "Password and code"
+// @Success 200 {object} object{message=string} "Two-factor authentication disabled"
+// @Failure 400 {object} object{error=string} "Invalid password or code"
+// @Failure 403 {object} object{error=string} "Two-factor authentication is required for the user's role"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.getCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	required, err := h.twoFactorRepo.IsRequired(middleware.GetRoles(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check two-factor policy",
+		})
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Two-factor authentication is required for your role",
+		})
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid password or code",
+		})
+		return
+	}
+
+	valid, err := h.checkSecondFactor(user, req.Code, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid password or code",
+		})
+		return
+	}
+
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = nil
+	if err := h.userRepo.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to disable two-factor authentication",
+		})
+		return
+	}
+
+	if err := h.twoFactorRepo.DeleteRecoveryCodes(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming with a TOTP code. The old codes stop working.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} object{recovery_codes=[]string} "New recovery codes"
+// @Failure 400 {object} object{error=string} "Invalid code or two-factor authentication not enabled"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.getCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	valid, err := h.checkTOTP(user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid code",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorRepo.ReplaceRecoveryCodes(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// VerifyTwoFactorLogin completes a two-factor login
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from /login and a TOTP code (or a recovery code) for an access token and refresh token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} object{message=string,user=models.UserResponse,token=string,refresh_token=string,expires_in=int} "Login successful"
+// @Failure 400 {object} object{error=string,details=string} "Invalid request data"
+// @Failure 401 {object} object{error=string} "Invalid challenge token or code"
+// @Failure 429 {object} object{error=string,retry_after_seconds=int} "Too many failed attempts"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /auth/2fa/verify [post]
+func (h *UserHandler) VerifyTwoFactorLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Either code or recovery_code is required",
+		})
+		return
+	}
+
+	claims, err := h.jwtManager.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired challenge token",
+		})
+		return
+	}
+
+	// A password change since the challenge was issued invalidates it
+	user, err := h.userRepo.GetByID(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion || !user.TwoFactorEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired challenge token",
+		})
+		return
+	}
+
+	// Wrong codes count as failed logins for the account
+	wait, err := h.loginThrottle.Check(services.AccountAttemptKey(user.Email), services.IPAttemptKey(c.ClientIP()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check login attempts",
+		})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
+	valid, err := h.checkSecondFactor(user, req.Code, req.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify code",
+		})
+		return
+	}
+	if !valid {
+		h.recordLoginFailure(c, user.Email, user)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid code",
+		})
+		return
+	}
+
+	if err := h.loginThrottle.Reset(services.AccountAttemptKey(user.Email)); err != nil {
+		log.Printf("Failed to reset login attempts for %s: %v", user.Email, err)
+	}
+
+	tokens, err := h.issueTokenPair(c, user, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// checkTOTP validates a TOTP code and records its time step so it cannot be replayed
+func (h *UserHandler) checkTOTP(user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil || code == "" {
+		return false, nil
+	}
+
+	step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return h.userRepo.ConsumeTOTPStep(user.ID, step)
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code
+func (h *UserHandler) checkSecondFactor(user *models.User, code, recoveryCode string) (bool, error) {
+	valid, err := h.checkTOTP(user, code)
+	if err != nil || valid {
+		return valid, err
+	}
+
+	if recoveryCode == "" {
+		return false, nil
+	}
+	return h.twoFactorRepo.ConsumeRecoveryCode(user.ID, recoveryCode)
+}
+
+// getCurrentUser loads a fresh copy of the authenticated user for handlers that
+// save it, writing an error response if it cannot
+func (h *UserHandler) getCurrentUser(c *gin.Context) (*models.User, bool) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+		return nil, false
+	}
+
+	return user, true
+}
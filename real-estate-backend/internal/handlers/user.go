@@ -1,16 +1,19 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"real-estate-backend/pkg/auth"
+	"real-estate-backend/internal/middleware"
 	"real-estate-backend/internal/models"
 	"real-estate-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // UserHandler handles user-related HTTP requests
@@ -18,7 +21,11 @@ type UserHandler struct {
 	userRepo              *models.UserRepository
 	jwtManager            *auth.JWTManager
 	emailVerificationRepo *models.EmailVerificationRepository
+	sessionRepo           *models.UserSessionRepository
+	twoFactorRepo         *models.TwoFactorRepository
+	loginThrottle         *services.LoginThrottle
 	emailService          *services.EmailService
+	auditRepo             *models.AuditLogRepository
 }
 
 // NewUserHandler creates a new user handler
@@ -26,13 +33,21 @@ func NewUserHandler(
 	userRepo *models.UserRepository,
 	jwtManager *auth.JWTManager,
 	emailVerificationRepo *models.EmailVerificationRepository,
+	sessionRepo *models.UserSessionRepository,
+	twoFactorRepo *models.TwoFactorRepository,
+	loginThrottle *services.LoginThrottle,
 	emailService *services.EmailService,
+	auditRepo *models.AuditLogRepository,
 ) *UserHandler {
 	return &UserHandler{
 		userRepo:              userRepo,
 		jwtManager:            jwtManager,
 		emailVerificationRepo: emailVerificationRepo,
+		sessionRepo:           sessionRepo,
+		twoFactorRepo:         twoFactorRepo,
+		loginThrottle:         loginThrottle,
 		emailService:          emailService,
+		auditRepo:             auditRepo,
 	}
 }
 
@@ -43,7 +58,7 @@ func NewUserHandler(
 // @Accept json
 // @Produce json
 // @Param user body models.CreateUserRequest true "User registration data"
-// @Success 201 {object} object{message=string,user=models.UserResponse,token=string} "User created successfully"
+// @Success 201 {object} object{message=string,user=models.UserResponse,token=string,refresh_token=string,expires_in=int} "User created successfully"
 // @Failure 400 {object} object{error=string,details=string} "Invalid request data"
 // @Failure 409 {object} object{error=string} "Email or phone already exists"
 // @Failure 500 {object} object{error=string} "Internal server error"
@@ -73,6 +88,17 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Phone numbers are stored as 2547..., so the same number cannot register
+	// again in another format
+	phone, err := services.NormalizePhoneNumber(req.PhoneNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid phone number format",
+		})
+		return
+	}
+	req.PhoneNumber = phone
+
 	// Check if phone number already exists
 	phoneExists, err := h.userRepo.PhoneExists(req.PhoneNumber)
 	if err != nil {
@@ -113,8 +139,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Email, string(user.UserType))
+	// Start a session for the new user
+	tokens, err := h.issueTokenPair(c, user, false)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -128,7 +154,10 @@ func (h *UserHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User created successfully. Please check your email to verify your account.",
 		"user":    user.ToResponse(),
-		"token":   token,
+		"token":   tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
 		"email_verification_required": true,
 	})
 }
@@ -165,14 +194,15 @@ func (h *UserHandler) sendVerificationEmailAsync(user *models.User) {
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Users with two-factor authentication enabled get a challenge token instead, to be exchanged at /auth/2fa/verify.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param credentials body models.LoginRequest true "User login credentials"
-// @Success 200 {object} object{message=string,user=models.UserResponse,token=string} "Login successful"
+// @Success 200 {object} object{message=string,user=models.UserResponse,token=string,refresh_token=string,expires_in=int,two_factor_required=bool,challenge_token=string} "Login successful or second factor required"
 // @Failure 400 {object} object{error=string,details=string} "Invalid request data"
 // @Failure 401 {object} object{error=string} "Invalid credentials or account deactivated"
+// @Failure 429 {object} object{error=string,retry_after_seconds=int} "Too many failed attempts"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /login [post]
 func (h *UserHandler) Login(c *gin.Context) {
@@ -185,10 +215,24 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts on a throttled account or from a throttled IP
+	wait, err := h.loginThrottle.Check(services.AccountAttemptKey(req.Email), services.IPAttemptKey(c.ClientIP()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check login attempts",
+		})
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(c, wait)
+		return
+	}
+
 	// Get user by email
 	user, err := h.userRepo.GetByEmail(req.Email)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == gorm.ErrRecordNotFound {
+			h.recordLoginFailure(c, req.Email, nil)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid email or password",
 			})
@@ -202,6 +246,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	// Check password
 	if !user.CheckPassword(req.Password) {
+		h.recordLoginFailure(c, req.Email, user)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid email or password",
 		})
@@ -226,8 +271,32 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Email, string(user.UserType))
+	// Enrolled users must present a second factor before a session is started.
+	// Their failure count is kept until the second factor succeeds.
+	if user.TwoFactorEnabled {
+		challengeToken, err := h.jwtManager.GenerateChallengeToken(user.ID, user.TokenVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to generate token",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
+			"expires_in":          int(h.jwtManager.ChallengeTokenTTL().Seconds()),
+		})
+		return
+	}
+
+	if err := h.loginThrottle.Reset(services.AccountAttemptKey(req.Email)); err != nil {
+		log.Printf("Failed to reset login attempts for %s: %v", user.Email, err)
+	}
+
+	// Start a new session
+	tokens, err := h.issueTokenPair(c, user, false)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -236,9 +305,12 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user.ToResponse(),
-		"token":   token,
+		"message":       "Login successful",
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -255,34 +327,8 @@ func (h *UserHandler) Login(c *gin.Context) {
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /profile [get]
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User ID not found in context",
-		})
-		return
-	}
-
-	userUUID, ok := userID.(uuid.UUID)
+	user, ok := currentUser(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user ID format",
-		})
-		return
-	}
-
-	// Get user
-	user, err := h.userRepo.GetByID(userUUID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "User not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user",
-		})
 		return
 	}
 
@@ -291,27 +337,19 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	})
 }
 
-// GetPendingAgents handles getting all agents waiting for approval (admin only)
+// GetPendingAgents handles getting all agents waiting for approval (requires agent:read)
 // @Summary Get pending agents
 // @Description Get all agents waiting for admin approval
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Success 200 {object} object{agents=[]models.UserResponse} "Pending agents"
+// @Success 200 {object} object{agents=[]models.AdminUserResponse} "Pending agents"
 // @Failure 401 {object} object{error=string} "Unauthorized"
-// @Failure 403 {object} object{error=string} "Forbidden - Admin access required"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /admin/pending-agents [get]
 func (h *UserHandler) GetPendingAgents(c *gin.Context) {
-	// Check if user is admin
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Admin access required",
-		})
-		return
-	}
-
 	// Get pending agents
 	agents, err := h.userRepo.GetPendingAgents()
 	if err != nil {
@@ -322,9 +360,9 @@ func (h *UserHandler) GetPendingAgents(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var agentResponses []models.UserResponse
+	var agentResponses []models.AdminUserResponse
 	for _, agent := range agents {
-		agentResponses = append(agentResponses, *agent.ToResponse())
+		agentResponses = append(agentResponses, *agent.ToAdminResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -332,27 +370,27 @@ func (h *UserHandler) GetPendingAgents(c *gin.Context) {
 	})
 }
 
-// ApproveAgent handles approving an agent (admin only)
+// ApproveAgent handles approving an agent (requires agent:approve)
 // @Summary Approve an agent
-// @Description Approve an agent to allow property management
+// @Description Approve an agent to allow property management. The agent's national ID, KRA PIN certificate and estate agent licence must be verified first.
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param agentId path string true "Agent ID"
-// @Success 200 {object} object{message=string,agent=models.UserResponse} "Agent approved successfully"
+// @Success 200 {object} object{message=string,agent=models.AdminUserResponse} "Agent approved successfully"
 // @Failure 400 {object} object{error=string} "Invalid agent ID"
 // @Failure 401 {object} object{error=string} "Unauthorized"
-// @Failure 403 {object} object{error=string} "Forbidden - Admin access required"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
 // @Failure 404 {object} object{error=string} "Agent not found"
+// @Failure 409 {object} object{error=string,details=string} "Required documents not verified"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /admin/approve-agent/{agentId} [post]
 func (h *UserHandler) ApproveAgent(c *gin.Context) {
-	// Check if user is admin
-	adminID, isAdmin := h.getAdminID(c)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Admin access required",
+	adminID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found in context",
 		})
 		return
 	}
@@ -367,8 +405,26 @@ func (h *UserHandler) ApproveAgent(c *gin.Context) {
 		return
 	}
 
+	var before map[string]interface{}
+	if agent, err := h.userRepo.GetByID(agentID); err == nil {
+		before = models.AuditSnapshot(agent.ToResponse())
+	}
+
 	// Approve the agent
 	err = h.userRepo.ApproveAgent(agentID, adminID)
+	if errors.Is(err, models.ErrKYCIncomplete) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Agent's required documents have not all been verified",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Agent not found",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to approve agent",
@@ -386,33 +442,31 @@ func (h *UserHandler) ApproveAgent(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditRepo, &models.AuditLog{
+		Action:     models.AuditAgentApprove,
+		TargetType: models.AuditTargetUser,
+		TargetID:   agent.ID.String(),
+	}, before, models.AuditSnapshot(agent.ToResponse()))
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Agent approved successfully",
-		"agent":   agent.ToResponse(),
+		"agent":   agent.ToAdminResponse(),
 	})
 }
 
-// GetAllAgents handles getting all agents (admin only)
+// GetAllAgents handles getting all agents (requires agent:read)
 // @Summary Get all agents
 // @Description Get all agents (approved and pending)
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Success 200 {object} object{agents=[]models.UserResponse} "All agents"
+// @Success 200 {object} object{agents=[]models.AdminUserResponse} "All agents"
 // @Failure 401 {object} object{error=string} "Unauthorized"
-// @Failure 403 {object} object{error=string} "Forbidden - Admin access required"
+// @Failure 403 {object} object{error=string} "Forbidden - Missing permission"
 // @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /admin/agents [get]
 func (h *UserHandler) GetAllAgents(c *gin.Context) {
-	// Check if user is admin
-	if !h.isAdmin(c) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Admin access required",
-		})
-		return
-	}
-
 	// Get all agents
 	agents, err := h.userRepo.GetAllAgents()
 	if err != nil {
@@ -423,9 +477,9 @@ func (h *UserHandler) GetAllAgents(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var agentResponses []models.UserResponse
+	var agentResponses []models.AdminUserResponse
 	for _, agent := range agents {
-		agentResponses = append(agentResponses, *agent.ToResponse())
+		agentResponses = append(agentResponses, *agent.ToAdminResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -435,30 +489,47 @@ func (h *UserHandler) GetAllAgents(c *gin.Context) {
 
 // Helper functions
 
-// isAdmin checks if the current user is an admin
-func (h *UserHandler) isAdmin(c *gin.Context) bool {
-	userType, exists := c.Get("user_type")
-	if !exists {
-		return false
-	}
-	return userType == string(models.UserTypeAdmin)
-}
-
-// getAdminID gets the admin ID if the current user is an admin
-func (h *UserHandler) getAdminID(c *gin.Context) (uuid.UUID, bool) {
-	if !h.isAdmin(c) {
-		return uuid.Nil, false
-	}
-	
+// getUserID gets the authenticated user's ID from the context
+func getUserID(c *gin.Context) (uuid.UUID, bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		return uuid.Nil, false
 	}
-	
+
 	userUUID, ok := userID.(uuid.UUID)
+	return userUUID, ok
+}
+
+// currentUser returns the user loaded by the LoadUser middleware, writing an
+// error response if there is none. The user is read-only; see middleware.GetCurrentUser.
+func currentUser(c *gin.Context) (*models.User, bool) {
+	user, ok := middleware.GetCurrentUser(c)
 	if !ok {
-		return uuid.Nil, false
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// recordLoginFailure counts a failed login against the account and the client IP,
+// and emails the account owner when the failure locks the account
+func (h *UserHandler) recordLoginFailure(c *gin.Context, email string, user *models.User) {
+	if _, _, err := h.loginThrottle.RecordFailure(services.IPAttemptKey(c.ClientIP())); err != nil {
+		log.Printf("Failed to record login failure for IP %s: %v", c.ClientIP(), err)
+	}
+
+	locked, until, err := h.loginThrottle.RecordFailure(services.AccountAttemptKey(email))
+	if err != nil {
+		log.Printf("Failed to record login failure for %s: %v", email, err)
+		return
+	}
+
+	if locked && user != nil {
+		fullName := user.FirstName + " " + user.LastName
+		if err := h.emailService.SendAccountLockedEmail(user.Email, fullName, c.ClientIP(), until); err != nil {
+			log.Printf("Failed to send account locked email to %s: %v", user.Email, err)
+		}
 	}
-	
-	return userUUID, true
 }
@@ -12,7 +12,8 @@ import (
 	"github.com/google/uuid"
 )
 
-// AuthMiddleware creates a middleware for JWT authentication
+// AuthMiddleware creates a middleware for JWT authentication. LoadUser checks
+// that the token has not been revoked.
 func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -57,35 +58,37 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_type", claims.UserType)
+		c.Set("roles", rolesFromClaims(claims))
+		c.Set("mfa", claims.MFA)
+		c.Set("token_version", claims.TokenVersion)
 
 		c.Next()
 	}
 }
 
-// RequireUserType creates a middleware that requires specific user types
-func RequireUserType(allowedTypes ...string) gin.HandlerFunc {
+// RequirePermission creates a middleware that requires every listed permission
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userType, exists := c.Get("user_type")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User type not found in context",
-			})
-			c.Abort()
-			return
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":      "Insufficient permissions",
+					"permission": permission,
+				})
+				c.Abort()
+				return
+			}
 		}
 
-		userTypeStr, ok := userType.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Invalid user type format",
-			})
-			c.Abort()
-			return
-		}
+		c.Next()
+	}
+}
 
-		// Check if user type is allowed
-		for _, allowedType := range allowedTypes {
-			if userTypeStr == allowedType {
+// RequireAnyPermission creates a middleware that requires at least one of the listed permissions
+func RequireAnyPermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if HasPermission(c, permission) {
 				c.Next()
 				return
 			}
@@ -98,13 +101,87 @@ func RequireUserType(allowedTypes ...string) gin.HandlerFunc {
 	}
 }
 
+// HasPermission checks if the authenticated user's roles grant a permission
+func HasPermission(c *gin.Context, permission models.Permission) bool {
+	return models.HasPermission(GetRoles(c), permission)
+}
+
+// GetRoles returns the authenticated user's roles from the context
+func GetRoles(c *gin.Context) []models.Role {
+	roles, exists := c.Get("roles")
+	if !exists {
+		return nil
+	}
+	roleList, _ := roles.([]models.Role)
+	return roleList
+}
+
+// rolesFromClaims returns the roles carried by a token. Tokens issued before roles
+// were added to the claims fall back to the base role of their user type.
+func rolesFromClaims(claims *auth.Claims) []models.Role {
+	if len(claims.Roles) == 0 {
+		return []models.Role{models.UserType(claims.UserType).BaseRole()}
+	}
+	roles := make([]models.Role, len(claims.Roles))
+	for i, role := range claims.Roles {
+		roles[i] = models.Role(role)
+	}
+	return roles
+}
+
+// TwoFactorPolicyInterface reports whether roles must use two-factor authentication
+type TwoFactorPolicyInterface interface {
+	IsRequired(roles []models.Role) (bool, error)
+}
+
+// RequireTwoFactor creates a middleware that rejects sessions established without a
+// second factor when any of the user's roles requires two-factor authentication
+func RequireTwoFactor(policy TwoFactorPolicyInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mfa") {
+			c.Next()
+			return
+		}
+
+		required, err := policy.IsRequired(GetRoles(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check two-factor policy",
+			})
+			c.Abort()
+			return
+		}
+
+		if required {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Two-factor authentication is required for your role. Enable it and log in again.",
+				"two_factor_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // UserRepositoryInterface defines the interface for user repository
 type UserRepositoryInterface interface {
 	GetByID(id uuid.UUID) (*models.User, error)
 }
 
-// RequireVerifiedEmail creates a middleware that requires users to have verified emails
-func RequireVerifiedEmail(userRepo UserRepositoryInterface) gin.HandlerFunc {
+// UserCacheInterface defines the cache LoadUser reads through
+type UserCacheInterface interface {
+	Get(id uuid.UUID) (*models.User, bool)
+	Set(user *models.User)
+}
+
+// LoadUser creates a middleware that loads the authenticated user once per request
+// and stores it in the context as "current_user". Must run after AuthMiddleware.
+// Tokens are rejected once the user's token version has moved past the one they
+// were issued with, e.g. after a password change or "log out everywhere"; the
+// repository invalidates the cached user when it bumps the version.
+func LoadUser(userRepo UserRepositoryInterface, cache UserCacheInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDInterface, exists := c.Get("user_id")
 		if !exists {
@@ -125,9 +202,49 @@ func RequireVerifiedEmail(userRepo UserRepositoryInterface) gin.HandlerFunc {
 			return
 		}
 
-		// Get user from database to check verification status
-		user, err := userRepo.GetByID(userID)
-		if err != nil {
+		user, cached := cache.Get(userID)
+		if !cached {
+			var err error
+			user, err = userRepo.GetByID(userID)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "User not found",
+				})
+				c.Abort()
+				return
+			}
+			cache.Set(user)
+		}
+
+		if user.TokenVersion != c.GetInt("token_version") {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("current_user", user)
+		c.Next()
+	}
+}
+
+// GetCurrentUser returns the user loaded by LoadUser. Handlers must not save it:
+// it may be up to one cache TTL old, so load a fresh copy before updating.
+func GetCurrentUser(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("current_user")
+	if !exists {
+		return nil, false
+	}
+	currentUser, ok := user.(*models.User)
+	return currentUser, ok
+}
+
+// RequireVerifiedEmail creates a middleware that requires users to have verified emails
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := GetCurrentUser(c)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "User not found",
 			})
@@ -145,35 +262,41 @@ func RequireVerifiedEmail(userRepo UserRepositoryInterface) gin.HandlerFunc {
 			return
 		}
 
-	c.Next()
+		c.Next()
 	}
 }
 
 // RequireApprovedAgent creates a middleware that requires agents to be approved by admin
-func RequireApprovedAgent(userRepo UserRepositoryInterface) gin.HandlerFunc {
+func RequireApprovedAgent() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userIDInterface, exists := c.Get("user_id")
-		if !exists {
+		user, ok := GetCurrentUser(c)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User ID not found in context",
+				"error": "User not found",
 			})
 			c.Abort()
 			return
 		}
 
-		// Convert userID to UUID
-		userID, ok := userIDInterface.(uuid.UUID)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Invalid user ID format",
+		// Check if user can manage properties (agents need approval)
+		if !user.CanManageProperties() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Agent approval required. Please wait for admin approval before managing properties.",
+				"approval_required": true,
 			})
 			c.Abort()
 			return
 		}
 
-		// Get user from database to check approval status
-		user, err := userRepo.GetByID(userID)
-		if err != nil {
+		c.Next()
+	}
+}
+
+// RequireVerifiedPhone creates a middleware that requires users to have verified their phone number
+func RequireVerifiedPhone() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := GetCurrentUser(c)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "User not found",
 			})
@@ -181,11 +304,10 @@ func RequireApprovedAgent(userRepo UserRepositoryInterface) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user can manage properties (admin always can, agent needs approval)
-		if !user.CanManageProperties() {
+		if !user.IsPhoneVerified {
 			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Agent approval required. Please wait for admin approval before managing properties.",
-				"approval_required": true,
+				"error":                       "Phone verification required. Please verify your phone number before making payments.",
+				"phone_verification_required": true,
 			})
 			c.Abort()
 			return
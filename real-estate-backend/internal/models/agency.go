@@ -0,0 +1,119 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Agency represents an estate agency whose agents share a portfolio of listings
+type Agency struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name          string         `json:"name" gorm:"not null"`
+	LogoURL       *string        `json:"logo_url,omitempty"`
+	LogoPublicID  *string        `json:"-"` // Cloudinary public ID of the logo
+	Email         string         `json:"email" gorm:"not null"`
+	PhoneNumber   string         `json:"phone_number" gorm:"not null"`
+	Website       *string        `json:"website,omitempty"`
+	Address       *string        `json:"address,omitempty"`
+	LicenceNumber string         `json:"licence_number" gorm:"uniqueIndex;not null"` // Estate Agents Registration Board licence
+	CreatedBy     *uuid.UUID     `json:"-" gorm:"type:uuid"`                         // Admin who created the agency
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CreateAgencyRequest represents the request to create an agency
+type CreateAgencyRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Email         string  `json:"email" binding:"required,email"`
+	PhoneNumber   string  `json:"phone_number" binding:"required"`
+	Website       *string `json:"website,omitempty" binding:"omitempty,url"`
+	Address       *string `json:"address,omitempty"`
+	LicenceNumber string  `json:"licence_number" binding:"required"`
+}
+
+// UpdateAgencyRequest represents the request to update an agency's details
+type UpdateAgencyRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1"`
+	Email       *string `json:"email,omitempty" binding:"omitempty,email"`
+	PhoneNumber *string `json:"phone_number,omitempty"`
+	Website     *string `json:"website,omitempty" binding:"omitempty,url"`
+	Address     *string `json:"address,omitempty"`
+}
+
+// AgencyMemberRequest represents the request to add an agent to an agency
+type AgencyMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// ReassignPropertyRequest represents the request to move a listing to another agent of the agency
+type ReassignPropertyRequest struct {
+	AgentID uuid.UUID `json:"agent_id" binding:"required"`
+}
+
+// BeforeCreate GORM hook to set ID
+func (a *Agency) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for Agency model
+func (Agency) TableName() string {
+	return "agencies"
+}
+
+// AgencyRepository handles database operations for agencies
+type AgencyRepository struct {
+	db *gorm.DB
+}
+
+// NewAgencyRepository creates a new agency repository
+func NewAgencyRepository(db *gorm.DB) *AgencyRepository {
+	return &AgencyRepository{db: db}
+}
+
+// Create creates a new agency
+func (r *AgencyRepository) Create(agency *Agency) error {
+	return r.db.Create(agency).Error
+}
+
+// GetByID retrieves an agency by ID
+func (r *AgencyRepository) GetByID(id uuid.UUID) (*Agency, error) {
+	var agency Agency
+	err := r.db.Where("id = ?", id).First(&agency).Error
+	if err != nil {
+		return nil, err
+	}
+	return &agency, nil
+}
+
+// GetAll retrieves every agency ordered by name
+func (r *AgencyRepository) GetAll() ([]Agency, error) {
+	var agencies []Agency
+	err := r.db.Order("name").Find(&agencies).Error
+	return agencies, err
+}
+
+// Update updates an agency
+func (r *AgencyRepository) Update(agency *Agency) error {
+	return r.db.Save(agency).Error
+}
+
+// LicenceExists checks if an agency with the licence number exists
+func (r *AgencyRepository) LicenceExists(licenceNumber string) (bool, error) {
+	var count int64
+	err := r.db.Model(&Agency{}).Where("licence_number = ?", licenceNumber).Count(&count).Error
+	return count > 0, err
+}
+
+// GetMembers retrieves the active users belonging to an agency
+func (r *AgencyRepository) GetMembers(agencyID uuid.UUID) ([]User, error) {
+	var members []User
+	err := r.db.Where("agency_id = ? AND is_active = ?", agencyID, true).
+		Order("first_name, last_name").Find(&members).Error
+	return members, err
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AmenityCategory groups related amenities
+type AmenityCategory string
+
+const (
+	AmenityCategorySecurity   AmenityCategory = "security"
+	AmenityCategoryUtilities  AmenityCategory = "utilities"
+	AmenityCategoryKitchen    AmenityCategory = "kitchen"
+	AmenityCategoryInterior   AmenityCategory = "interior"
+	AmenityCategoryRecreation AmenityCategory = "recreation"
+	AmenityCategoryBuilding   AmenityCategory = "building"
+)
+
+// Amenity is an entry of the amenity catalog. Properties store the keys of their
+// amenities, so listings can be filtered on them.
+type Amenity struct {
+	Key      string          `json:"key"`
+	Label    string          `json:"label"`
+	LabelSw  string          `json:"label_sw"` // Swahili label
+	Category AmenityCategory `json:"category"`
+}
+
+// AmenityCatalog lists the amenities agents can pick from, in display order
+var AmenityCatalog = []Amenity{
+	{"security_guard", "24/7 Security", "Ulinzi wa saa 24", AmenityCategorySecurity},
+	{"cctv", "CCTV Surveillance", "Kamera za usalama (CCTV)", AmenityCategorySecurity},
+	{"electric_fence", "Electric Fence", "Uzio wa umeme", AmenityCategorySecurity},
+	{"gated_community", "Gated Community", "Makazi yenye lango", AmenityCategorySecurity},
+	{"alarm_system", "Alarm System", "Mfumo wa king'ora", AmenityCategorySecurity},
+
+	{"borehole", "Borehole Water", "Maji ya kisima", AmenityCategoryUtilities},
+	{"mains_water", "Mains Water", "Maji ya bomba", AmenityCategoryUtilities},
+	{"water_tank", "Water Storage Tank", "Tangi la kuhifadhi maji", AmenityCategoryUtilities},
+	{"backup_generator", "Backup Generator", "Jenereta ya akiba", AmenityCategoryUtilities},
+	{"solar_water_heating", "Solar Water Heating", "Kupasha maji kwa nishati ya jua", AmenityCategoryUtilities},
+	{"prepaid_electricity", "Prepaid Electricity Meter", "Mita ya umeme ya malipo ya awali", AmenityCategoryUtilities},
+	{"wifi", "Wi-Fi / Internet", "Intaneti (Wi-Fi)", AmenityCategoryUtilities},
+
+	{"fitted_kitchen", "Fitted Kitchen", "Jiko lenye makabati", AmenityCategoryKitchen},
+	{"gas_cooker", "Gas Cooker", "Jiko la gesi", AmenityCategoryKitchen},
+	{"fridge", "Refrigerator", "Jokofu", AmenityCategoryKitchen},
+
+	{"air_conditioning", "Air Conditioning", "Kiyoyozi", AmenityCategoryInterior},
+	{"hot_shower", "Hot Shower", "Bafu ya maji moto", AmenityCategoryInterior},
+	{"wardrobes", "Built-in Wardrobes", "Makabati ya nguo ukutani", AmenityCategoryInterior},
+	{"balcony", "Balcony", "Roshani", AmenityCategoryInterior},
+	{"servant_quarters", "Servant Quarters (DSQ)", "Nyumba ya mfanyakazi (DSQ)", AmenityCategoryInterior},
+
+	{"swimming_pool", "Swimming Pool", "Bwawa la kuogelea", AmenityCategoryRecreation},
+	{"gym", "Gym", "Ukumbi wa mazoezi", AmenityCategoryRecreation},
+	{"playground", "Children's Playground", "Uwanja wa michezo wa watoto", AmenityCategoryRecreation},
+	{"garden", "Garden", "Bustani", AmenityCategoryRecreation},
+
+	{"lift", "Lift", "Lifti", AmenityCategoryBuilding},
+	{"visitor_parking", "Visitor Parking", "Maegesho ya wageni", AmenityCategoryBuilding},
+	{"pet_friendly", "Pet Friendly", "Wanyama vipenzi wanaruhusiwa", AmenityCategoryBuilding},
+	{"wheelchair_access", "Wheelchair Access", "Njia ya kiti cha magurudumu", AmenityCategoryBuilding},
+}
+
+// amenitiesByKey indexes AmenityCatalog
+var amenitiesByKey = func() map[string]Amenity {
+	byKey := make(map[string]Amenity, len(AmenityCatalog))
+	for _, amenity := range AmenityCatalog {
+		byKey[amenity.Key] = amenity
+	}
+	return byKey
+}()
+
+// LookupAmenity returns the catalog entry with the key
+func LookupAmenity(key string) (Amenity, bool) {
+	amenity, ok := amenitiesByKey[key]
+	return amenity, ok
+}
+
+// AmenitiesByCategory returns the catalog grouped by category, optionally only one category
+func AmenitiesByCategory(category AmenityCategory) map[AmenityCategory][]Amenity {
+	grouped := make(map[AmenityCategory][]Amenity)
+	for _, amenity := range AmenityCatalog {
+		if category == "" || amenity.Category == category {
+			grouped[amenity.Category] = append(grouped[amenity.Category], amenity)
+		}
+	}
+	return grouped
+}
+
+// Validate checks that every amenity is a catalog key set to true, the form
+// amenity filters match
+func (a Amenities) Validate() error {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, ok := LookupAmenity(key); !ok {
+			return fmt.Errorf("unknown amenity %q, see GET /amenities", key)
+		}
+		if value, ok := a[key].(bool); !ok || !value {
+			return fmt.Errorf("amenity %q must be true; leave out amenities the property lacks", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditChainLock is the Postgres advisory lock key that serializes appends so
+// each entry links to the one before it
+const auditChainLock = 7260417
+
+// AuditAction names a recorded action
+type AuditAction string
+
+const (
+	AuditAgentApprove        AuditAction = "agent.approve"
+	AuditAgentReject         AuditAction = "agent.reject"
+	AuditAgentSuspend        AuditAction = "agent.suspend"
+	AuditAgentRevoke         AuditAction = "agent.revoke"
+	AuditAgentReinstate      AuditAction = "agent.reinstate"
+	AuditKYCReview           AuditAction = "kyc.review"
+	AuditPropertyCreate      AuditAction = "property.create"
+	AuditPropertyUpdate      AuditAction = "property.update"
+	AuditPropertyDelete      AuditAction = "property.delete"
+	AuditPropertyReassign    AuditAction = "property.reassign"
+	AuditPropertyStatus      AuditAction = "property.status"
+	AuditPropertyApprove     AuditAction = "property.approve"
+	AuditPropertyReject      AuditAction = "property.reject"
+	AuditPropertyImageDelete AuditAction = "property_image.delete"
+	AuditPasswordChange      AuditAction = "password.change"
+	AuditPasswordReset       AuditAction = "password.reset"
+	AuditUserDeactivate      AuditAction = "user.deactivate"
+	AuditUserReactivate      AuditAction = "user.reactivate"
+	AuditSessionsRevoke      AuditAction = "user.revoke_sessions"
+	AuditRoleAssign          AuditAction = "role.assign"
+	AuditRoleRemove          AuditAction = "role.remove"
+	AuditTwoFactorPolicySet  AuditAction = "two_factor_policy.set"
+	AuditLockoutClear        AuditAction = "lockout.clear"
+	AuditInvitationCreate    AuditAction = "invitation.create"
+	AuditInvitationRevoke    AuditAction = "invitation.revoke"
+	AuditAgencyCreate        AuditAction = "agency.create"
+	AuditAgencyMemberAdd     AuditAction = "agency.member_add"
+	AuditAgencyMemberRemove  AuditAction = "agency.member_remove"
+	AuditReviewModerate      AuditAction = "review.moderate"
+)
+
+// AuditTargetType names the kind of entity an action applied to
+type AuditTargetType string
+
+const (
+	AuditTargetUser            AuditTargetType = "user"
+	AuditTargetProperty        AuditTargetType = "property"
+	AuditTargetPropertyImage   AuditTargetType = "property_image"
+	AuditTargetVerification    AuditTargetType = "verification"
+	AuditTargetAgency          AuditTargetType = "agency"
+	AuditTargetInvitation      AuditTargetType = "invitation"
+	AuditTargetTwoFactorPolicy AuditTargetType = "two_factor_policy"
+	AuditTargetLockout         AuditTargetType = "lockout"
+	AuditTargetReview          AuditTargetType = "review"
+)
+
+// AuditChange is the value of a field before and after an action
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditChanges maps field names to their changes, stored as JSON
+type AuditChanges map[string]AuditChange
+
+// Value implements the driver.Valuer interface for database storage
+func (a AuditChanges) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (a *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into AuditChanges", value)
+	}
+}
+
+// AuditLog is an entry in the append-only audit log. Each entry's hash covers
+// its content and the previous entry's hash, so altering or removing an entry
+// breaks the chain from that point on.
+type AuditLog struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Sequence   int64           `json:"sequence" gorm:"not null;uniqueIndex"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty" gorm:"type:uuid;index"` // Nil if no user is known to have acted
+	Action     AuditAction     `json:"action" gorm:"type:varchar(50);not null;index"`
+	TargetType AuditTargetType `json:"target_type" gorm:"type:varchar(50);not null"`
+	TargetID   string          `json:"target_id" gorm:"type:varchar(255);not null"`
+	Changes    AuditChanges    `json:"changes,omitempty" gorm:"type:jsonb"`
+	IPAddress  string          `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"not null;index"`
+	PrevHash   string          `json:"prev_hash" gorm:"type:varchar(64);not null"`
+	Hash       string          `json:"hash" gorm:"type:varchar(64);not null"`
+}
+
+// AuditLogFilters represents the filters of the audit log search
+type AuditLogFilters struct {
+	ActorID    *uuid.UUID
+	Action     AuditAction
+	TargetType AuditTargetType
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// BeforeCreate GORM hook to set ID
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// ComputeHash returns the hex SHA-256 of the previous entry's hash, a newline and
+// the canonical JSON of the entry's content: sequence, actor_id, action,
+// target_type, target_id, changes, ip_address, user_agent and created_at (RFC 3339
+// in UTC). Object keys in changes are sorted.
+func (a *AuditLog) ComputeHash() (string, error) {
+	changes, err := canonicalJSON(a.Changes)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(struct {
+		Sequence   int64           `json:"sequence"`
+		ActorID    *uuid.UUID      `json:"actor_id"`
+		Action     AuditAction     `json:"action"`
+		TargetType AuditTargetType `json:"target_type"`
+		TargetID   string          `json:"target_id"`
+		Changes    json.RawMessage `json:"changes"`
+		IPAddress  string          `json:"ip_address"`
+		UserAgent  string          `json:"user_agent"`
+		CreatedAt  string          `json:"created_at"`
+	}{
+		Sequence:   a.Sequence,
+		ActorID:    a.ActorID,
+		Action:     a.Action,
+		TargetType: a.TargetType,
+		TargetID:   a.TargetID,
+		Changes:    changes,
+		IPAddress:  a.IPAddress,
+		UserAgent:  a.UserAgent,
+		CreatedAt:  a.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(append([]byte(a.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON encodes changes the same way before they are stored and after
+// they are read back from jsonb, which reorders keys and turns numbers into floats
+func canonicalJSON(changes AuditChanges) (json.RawMessage, error) {
+	if changes == nil {
+		return json.RawMessage("null"), nil
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
+
+// AuditSnapshot captures the JSON fields of v for a before/after diff, leaving
+// out the omitted fields such as preloaded relationships
+func AuditSnapshot(v interface{}, omit ...string) map[string]interface{} {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(encoded, &snapshot); err != nil {
+		return nil
+	}
+	for _, field := range omit {
+		delete(snapshot, field)
+	}
+	return snapshot
+}
+
+// AuditDiff returns the fields that differ between two snapshots. A nil before
+// records every field of a created entity; a nil after every field of a deleted one.
+func AuditDiff(before, after map[string]interface{}) AuditChanges {
+	changes := AuditChanges{}
+	for field, value := range before {
+		if afterValue, ok := after[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = AuditChange{Before: value, After: after[field]}
+		}
+	}
+	for field, value := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = AuditChange{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// AuditLogRepository appends to and reads the audit log. It has no update or
+// delete operations, and the audit_logs table rejects them.
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Append adds an entry to the end of the chain, setting its sequence, timestamp
+// and hashes
+func (r *AuditLogRepository) Append(entry *AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Held until the transaction ends, so concurrent appends take turns
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+			return err
+		}
+
+		var last AuditLog
+		err := tx.Select("sequence", "hash").Order("sequence DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return err
+		}
+
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+		// Postgres keeps microseconds, so truncate before hashing
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.Hash, err = entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// Search returns a page of entries matching the filters, newest first, and the
+// total number of matches
+func (r *AuditLogRepository) Search(filters *AuditLogFilters) ([]AuditLog, int64, error) {
+	query := r.db.Model(&AuditLog{})
+
+	if filters.ActorID != nil {
+		query = query.Where("actor_id = ?", *filters.ActorID)
+	}
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+	if filters.TargetType != "" {
+		query = query.Where("target_type = ?", filters.TargetType)
+	}
+	if filters.TargetID != "" {
+		query = query.Where("target_id = ?", filters.TargetID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []AuditLog
+	err := query.Order("sequence DESC").Limit(filters.Limit).Offset(filters.Offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// Each calls fn for every entry created in [from, to), oldest first, loading
+// the entries in batches. Nil bounds are open.
+func (r *AuditLogRepository) Each(from, to *time.Time, fn func(*AuditLog) error) error {
+	var after int64
+	for {
+		query := r.db.Where("sequence > ?", after)
+		if from != nil {
+			query = query.Where("created_at >= ?", *from)
+		}
+		if to != nil {
+			query = query.Where("created_at < ?", *to)
+		}
+
+		var batch []AuditLog
+		if err := query.Order("sequence").Limit(500).Find(&batch).Error; err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < 500 {
+			return nil
+		}
+		after = batch[len(batch)-1].Sequence
+	}
+}
+
+// Verify recomputes the hash chain from the first entry. It returns the number
+// of entries checked and, if the chain is broken, the sequence of the first
+// entry that was altered, removed or inserted.
+func (r *AuditLogRepository) Verify() (int64, *int64, error) {
+	var checked int64
+	var broken *int64
+	prevHash := ""
+	expected := int64(1)
+
+	errBroken := errors.New("audit chain broken")
+	err := r.Each(nil, nil, func(entry *AuditLog) error {
+		linked, err := entry.linksTo(prevHash, expected)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			// A gap means the entry with the expected sequence was removed
+			sequence := expected
+			broken = &sequence
+			return errBroken
+		}
+		checked++
+		prevHash = entry.Hash
+		expected++
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBroken) {
+		return checked, nil, err
+	}
+	return checked, broken, nil
+}
+
+// linksTo reports whether the entry continues the chain after the entry with
+// prevHash: it has the expected sequence, records prevHash and its hash matches
+// its content
+func (a *AuditLog) linksTo(prevHash string, sequence int64) (bool, error) {
+	hash, err := a.ComputeHash()
+	if err != nil {
+		return false, err
+	}
+	return a.Sequence == sequence && a.PrevHash == prevHash && a.Hash == hash, nil
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// buildAuditChain links the entries the way Append does, numbering them from one
+func buildAuditChain(t *testing.T, entries []*AuditLog) []*AuditLog {
+	t.Helper()
+	prevHash := ""
+	created := time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC)
+	for i, entry := range entries {
+		entry.Sequence = int64(i + 1)
+		entry.PrevHash = prevHash
+		entry.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			t.Fatalf("ComputeHash() error = %v", err)
+		}
+		entry.Hash = hash
+		prevHash = hash
+	}
+	return entries
+}
+
+// verifyAuditChain checks the entries the way Verify does, returning the
+// sequence of the first broken entry or nil if the chain is intact
+func verifyAuditChain(t *testing.T, entries []*AuditLog) *int64 {
+	t.Helper()
+	prevHash := ""
+	expected := int64(1)
+	for _, entry := range entries {
+		linked, err := entry.linksTo(prevHash, expected)
+		if err != nil {
+			t.Fatalf("linksTo() error = %v", err)
+		}
+		if !linked {
+			return &expected
+		}
+		prevHash = entry.Hash
+		expected++
+	}
+	return nil
+}
+
+// jsonbRoundTrip stores the changes and reads them back as Postgres would
+// return them from a jsonb column: reformatted, with numbers decoded as floats
+func jsonbRoundTrip(t *testing.T, changes AuditChanges) AuditChanges {
+	t.Helper()
+	value, err := changes.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if value == nil {
+		return nil
+	}
+	var stored bytes.Buffer
+	if err := json.Indent(&stored, value.([]byte), "", "  "); err != nil {
+		t.Fatalf("json.Indent() error = %v", err)
+	}
+	var scanned AuditChanges
+	if err := scanned.Scan(stored.String()); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	return scanned
+}
+
+func sampleAuditEntries() []*AuditLog {
+	actorID := uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-8091a2b3c4d5")
+	return []*AuditLog{
+		{
+			ActorID:    &actorID,
+			Action:     AuditAction("agent.approve"),
+			TargetType: AuditTargetUser,
+			TargetID:   "a1b2c3d4-0000-4000-8000-000000000001",
+			Changes: AuditChanges{
+				"is_approved": {Before: false, After: true},
+			},
+			IPAddress: "203.0.113.7",
+			UserAgent: "Mozilla/5.0",
+		},
+		{
+			Action:     AuditAction("property.update"),
+			TargetType: AuditTargetProperty,
+			TargetID:   "a1b2c3d4-0000-4000-8000-000000000002",
+			Changes: AuditChanges{
+				"rent_amount": {Before: 45000, After: 47500.5},
+				"bedrooms":    {Before: 2, After: 3},
+				"features":    {After: map[string]interface{}{"parking": true, "balcony": false}},
+			},
+			IPAddress: "198.51.100.20",
+		},
+		{
+			ActorID:    &actorID,
+			Action:     AuditAction("property.delete"),
+			TargetType: AuditTargetProperty,
+			TargetID:   "a1b2c3d4-0000-4000-8000-000000000003",
+		},
+	}
+}
+
+func TestAuditLogComputeHash(t *testing.T) {
+	base := buildAuditChain(t, sampleAuditEntries())[1]
+	baseHash, err := base.ComputeHash()
+	if err != nil {
+		t.Fatalf("ComputeHash() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *AuditLog)
+		same   bool
+	}{
+		{"unchanged", func(a *AuditLog) {}, true},
+		{"stored hash is not part of the content", func(a *AuditLog) { a.Hash = "" }, true},
+		{"created_at in another zone", func(a *AuditLog) {
+			a.CreatedAt = a.CreatedAt.In(time.FixedZone("EAT", 3*60*60))
+		}, true},
+		{"changes after a jsonb round trip", func(a *AuditLog) { a.Changes = jsonbRoundTrip(t, a.Changes) }, true},
+		{"previous hash", func(a *AuditLog) { a.PrevHash = "0" + a.PrevHash[1:] }, false},
+		{"sequence", func(a *AuditLog) { a.Sequence++ }, false},
+		{"actor", func(a *AuditLog) {
+			actorID := uuid.New()
+			a.ActorID = &actorID
+		}, false},
+		{"action", func(a *AuditLog) { a.Action = AuditAction("property.delete") }, false},
+		{"target", func(a *AuditLog) { a.TargetID = "a1b2c3d4-0000-4000-8000-000000000009" }, false},
+		{"changed value", func(a *AuditLog) {
+			a.Changes["bedrooms"] = AuditChange{Before: 2, After: 4}
+		}, false},
+		{"removed change", func(a *AuditLog) { delete(a.Changes, "features") }, false},
+		{"ip address", func(a *AuditLog) { a.IPAddress = "192.0.2.1" }, false},
+		{"created_at", func(a *AuditLog) { a.CreatedAt = a.CreatedAt.Add(time.Microsecond) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := *base
+			entry.Changes = AuditChanges{}
+			for field, change := range base.Changes {
+				entry.Changes[field] = change
+			}
+			tt.modify(&entry)
+
+			hash, err := entry.ComputeHash()
+			if err != nil {
+				t.Fatalf("ComputeHash() error = %v", err)
+			}
+			if same := hash == baseHash; same != tt.same {
+				t.Errorf("hash unchanged = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestAuditChainVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(entries []*AuditLog) []*AuditLog
+		broken int64 // Zero if the chain is intact
+	}{
+		{"intact", func(entries []*AuditLog) []*AuditLog { return entries }, 0},
+		{"intact after a jsonb round trip", func(entries []*AuditLog) []*AuditLog {
+			for _, entry := range entries {
+				entry.Changes = jsonbRoundTrip(t, entry.Changes)
+			}
+			return entries
+		}, 0},
+		{"altered content", func(entries []*AuditLog) []*AuditLog {
+			entries[1].Changes["bedrooms"] = AuditChange{Before: 2, After: 5}
+			return entries
+		}, 2},
+		{"altered content after a jsonb round trip", func(entries []*AuditLog) []*AuditLog {
+			changes := jsonbRoundTrip(t, entries[1].Changes)
+			changes["rent_amount"] = AuditChange{Before: 45000.0, After: 40000.0}
+			entries[1].Changes = changes
+			return entries
+		}, 2},
+		{"altered content with a recomputed hash", func(entries []*AuditLog) []*AuditLog {
+			entries[0].TargetID = "a1b2c3d4-0000-4000-8000-000000000009"
+			entries[0].Hash, _ = entries[0].ComputeHash()
+			return entries
+		}, 2},
+		{"removed entry", func(entries []*AuditLog) []*AuditLog {
+			return append(entries[:1], entries[2:]...)
+		}, 2},
+		{"inserted entry", func(entries []*AuditLog) []*AuditLog {
+			forged := *entries[1]
+			forged.TargetID = "a1b2c3d4-0000-4000-8000-000000000009"
+			forged.Hash, _ = forged.ComputeHash()
+			return append(entries[:2], append([]*AuditLog{&forged}, entries[2:]...)...)
+		}, 3},
+		{"swapped entries", func(entries []*AuditLog) []*AuditLog {
+			entries[1], entries[2] = entries[2], entries[1]
+			return entries
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(buildAuditChain(t, sampleAuditEntries()))
+			broken := verifyAuditChain(t, entries)
+			switch {
+			case tt.broken == 0 && broken != nil:
+				t.Errorf("chain broken at %d, want intact", *broken)
+			case tt.broken != 0 && broken == nil:
+				t.Errorf("chain intact, want broken at %d", tt.broken)
+			case tt.broken != 0 && *broken != tt.broken:
+				t.Errorf("chain broken at %d, want %d", *broken, tt.broken)
+			}
+		})
+	}
+}
@@ -1,17 +1,22 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// ErrEmailVerificationUsed is returned when marking a verification that was already used
+var ErrEmailVerificationUsed = errors.New("email verification already used")
+
 // EmailVerification represents an email verification record
 type EmailVerification struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
 	Token     string    `json:"-" gorm:"type:varchar(255);uniqueIndex;not null"`
+	NewEmail  *string   `json:"new_email,omitempty" gorm:"type:varchar(255)"` // Set when confirming a change of address
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
 	IsUsed    bool      `json:"is_used" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -31,6 +36,12 @@ type VerifyEmailRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
+// ChangeEmailRequest represents a request to move the account to a new email address
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
 // BeforeCreate GORM hook to set ID
 func (e *EmailVerification) BeforeCreate(tx *gorm.DB) error {
 	if e.ID == uuid.Nil {
@@ -44,6 +55,12 @@ func (EmailVerification) TableName() string {
 	return "email_verifications"
 }
 
+// IsEmailChange reports whether the verification confirms a new email address
+// rather than the address the user registered with
+func (e *EmailVerification) IsEmailChange() bool {
+	return e.NewEmail != nil
+}
+
 // IsExpired checks if the verification token is expired
 func (e *EmailVerification) IsExpired() bool {
 	return time.Now().After(e.ExpiresAt)
@@ -74,19 +91,46 @@ func (r *EmailVerificationRepository) GetByToken(token string) (*EmailVerificati
 	return &verification, nil
 }
 
-// GetByUserID retrieves the latest email verification for a user
+// GetByUserID retrieves the latest verification of the address a user registered with
 func (r *EmailVerificationRepository) GetByUserID(userID uuid.UUID) (*EmailVerification, error) {
 	var verification EmailVerification
-	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&verification).Error
+	err := r.db.Where("user_id = ? AND new_email IS NULL", userID).Order("created_at DESC").First(&verification).Error
 	if err != nil {
 		return nil, err
 	}
 	return &verification, nil
 }
 
-// MarkAsUsed marks a verification as used
+// GetPendingEmailChange retrieves the latest unused email change for a user
+func (r *EmailVerificationRepository) GetPendingEmailChange(userID uuid.UUID) (*EmailVerification, error) {
+	var verification EmailVerification
+	err := r.db.Where("user_id = ? AND new_email IS NOT NULL AND is_used = false", userID).
+		Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// CancelPendingEmailChanges marks a user's unused email changes as used so only
+// the newest link can switch the address
+func (r *EmailVerificationRepository) CancelPendingEmailChanges(userID uuid.UUID) error {
+	return r.db.Model(&EmailVerification{}).
+		Where("user_id = ? AND new_email IS NOT NULL AND is_used = false", userID).
+		Update("is_used", true).Error
+}
+
+// MarkAsUsed marks a verification as used, returning ErrEmailVerificationUsed if
+// it already was, so that a link only takes effect once
 func (r *EmailVerificationRepository) MarkAsUsed(id uuid.UUID) error {
-	return r.db.Model(&EmailVerification{}).Where("id = ?", id).Update("is_used", true).Error
+	result := r.db.Model(&EmailVerification{}).Where("id = ? AND is_used = ?", id, false).Update("is_used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmailVerificationUsed
+	}
+	return nil
 }
 
 // DeleteByUserID deletes all verification records for a user (cleanup)
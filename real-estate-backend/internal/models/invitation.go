@@ -0,0 +1,171 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvitationAlreadyUsed is returned when an invitation was accepted or revoked concurrently
+var ErrInvitationAlreadyUsed = errors.New("invitation has already been used")
+
+// Invitation represents an admin-issued invite for a new admin, staff or landlord account.
+// Only the SHA-256 hash of the emailed token is stored.
+type Invitation struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Email      string     `json:"email" gorm:"not null;index"`
+	UserType   UserType   `json:"user_type" gorm:"not null;type:varchar(20)"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	InvitedBy  *uuid.UUID `json:"invited_by,omitempty" gorm:"type:uuid"` // Nil for the bootstrap invitation
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID `json:"accepted_by,omitempty" gorm:"type:uuid"` // User created from the invitation
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CreateInvitationRequest represents the request to invite a new admin, staff member or landlord
+type CreateInvitationRequest struct {
+	Email    string   `json:"email" binding:"required,email"`
+	UserType UserType `json:"user_type" binding:"required,oneof=admin staff landlord"`
+}
+
+// AcceptInvitationRequest represents the request to accept an invitation and set a password
+type AcceptInvitationRequest struct {
+	Token           string `json:"token" binding:"required"`
+	FirstName       string `json:"first_name" binding:"required"`
+	LastName        string `json:"last_name" binding:"required"`
+	PhoneNumber     string `json:"phone_number" binding:"required"`
+	Password        string `json:"password" binding:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+// HashInvitationToken returns the hex-encoded SHA-256 hash of an invitation token
+func HashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BeforeCreate GORM hook to set ID
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for Invitation model
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// IsExpired checks if the invitation has expired
+func (i *Invitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+// IsPending checks if the invitation can still be accepted
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && !i.IsExpired()
+}
+
+// InvitationRepository handles database operations for invitations
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// Create revokes any pending invitation for the same email and stores the new one
+func (r *InvitationRepository) Create(invitation *Invitation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Invitation{}).
+			Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.Email).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(invitation).Error
+	})
+}
+
+// GetByID retrieves an invitation by ID
+func (r *InvitationRepository) GetByID(id uuid.UUID) (*Invitation, error) {
+	var invitation Invitation
+	err := r.db.Where("id = ?", id).First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetByToken retrieves an invitation by its plain token
+func (r *InvitationRepository) GetByToken(token string) (*Invitation, error) {
+	var invitation Invitation
+	err := r.db.Where("token_hash = ?", HashInvitationToken(token)).First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetPending retrieves all invitations that can still be accepted
+func (r *InvitationRepository) GetPending() ([]Invitation, error) {
+	var invitations []Invitation
+	err := r.db.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// HasPending checks if an email has an invitation that can still be accepted
+func (r *InvitationRepository) HasPending(email string) (bool, error) {
+	var count int64
+	err := r.db.Model(&Invitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", email, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Revoke revokes a pending invitation
+func (r *InvitationRepository) Revoke(id uuid.UUID) error {
+	result := r.db.Model(&Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationAlreadyUsed
+	}
+	return nil
+}
+
+// Accept creates the invited user and marks the invitation as accepted atomically.
+// It returns ErrInvitationAlreadyUsed if the invitation was accepted or revoked first.
+func (r *InvitationRepository) Accept(invitation *Invitation, user *User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+			Updates(map[string]interface{}{"accepted_at": time.Now(), "accepted_by": user.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationAlreadyUsed
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt tracks recent failed attempts for a throttling key such as
+// "account:jane@example.com" or "ip:203.0.113.7"
+type LoginAttempt struct {
+	Key           string     `json:"key" gorm:"type:varchar(320);primaryKey"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"not null"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" gorm:"index"`
+}
+
+// TableName returns the table name for LoginAttempt model
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// LoginAttemptRepository stores failed attempts in the database so that every
+// instance of a multi-instance deployment sees the same counters
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// RecordFailure adds a failure for the key and returns the number of failures in
+// the current window. Failures older than the window are forgotten.
+func (r *LoginAttemptRepository) RecordFailure(key string, window time.Duration, now time.Time) (int, error) {
+	var failures int
+	err := r.db.Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`,
+		key, now, now.Add(-window)).Scan(&failures).Error
+	return failures, err
+}
+
+// Lock locks the key until the given time
+func (r *LoginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&LoginAttempt{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+// Get retrieves the attempts for a key, or nil if there are none
+func (r *LoginAttemptRepository) Get(key string) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := r.db.Where("key = ?", key).First(&attempt).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Clear forgets all failures and any lock for a key
+func (r *LoginAttemptRepository) Clear(key string) error {
+	return r.db.Where("key = ?", key).Delete(&LoginAttempt{}).Error
+}
+
+// GetLocked retrieves the keys that are locked at the given time
+func (r *LoginAttemptRepository) GetLocked(now time.Time) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := r.db.Where("locked_until > ?", now).Order("locked_until DESC").Find(&attempts).Error
+	return attempts, err
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Permission is a named capability that routes and handlers check for
+type Permission string
+
+const (
+	PermPropertyWriteOwn Permission = "property:write:own" // Create and manage one's own listings
+	PermPropertyWriteAny Permission = "property:write:any" // Manage any listing
+	PermPropertyModerate Permission = "property:moderate"  // Approve or reject listings before they go public
+	PermAgentRead        Permission = "agent:read"
+	PermAgentApprove     Permission = "agent:approve"
+	PermUserInvite       Permission = "user:invite"
+	PermUserRead         Permission = "user:read"   // Browse the user directory
+	PermUserManage       Permission = "user:manage" // Deactivate and reactivate accounts
+	PermSessionRevoke    Permission = "session:revoke"
+	PermRoleAssign       Permission = "role:assign"
+	PermRentalApply      Permission = "rental:apply" // Apply for rentals and pay rent
+	PermOwnerRead        Permission = "owner:read"   // View one's own units, leases, payments and statements
+	PermReviewWrite      Permission = "review:write" // Review properties rented and agents dealt with
+	PermReviewModerate   Permission = "review:moderate"
+	PermPaymentRead      Permission = "payment:read"
+	PermPaymentRefund    Permission = "payment:refund"
+	PermAgencyManage     Permission = "agency:manage"   // Manage one's own agency and its listings
+	PermAgencyAdmin      Permission = "agency:admin"    // Create agencies and assign agents to them
+	PermSecurityManage   Permission = "security:manage" // Security policies such as required 2FA
+	PermStatsRead        Permission = "stats:read"      // Dashboard statistics
+	PermAuditRead        Permission = "audit:read"      // Search, export and verify the audit log
+)
+
+// Role is a named set of permissions. Every user holds the role matching their
+// user type and may be assigned additional roles.
+type Role string
+
+const (
+	RoleAdmin         Role = "admin"
+	RoleAgent         Role = "agent"
+	RoleTenant        Role = "tenant"
+	RoleLandlord      Role = "landlord"
+	RoleStaff         Role = "staff"
+	RoleSupport       Role = "support"
+	RoleModerator     Role = "moderator"
+	RoleAgencyManager Role = "agency_manager"
+)
+
+// AllPermissions lists every permission
+var AllPermissions = []Permission{
+	PermPropertyWriteOwn,
+	PermPropertyWriteAny,
+	PermPropertyModerate,
+	PermAgentRead,
+	PermAgentApprove,
+	PermUserInvite,
+	PermUserRead,
+	PermUserManage,
+	PermSessionRevoke,
+	PermRoleAssign,
+	PermRentalApply,
+	PermOwnerRead,
+	PermReviewWrite,
+	PermReviewModerate,
+	PermPaymentRead,
+	PermPaymentRefund,
+	PermAgencyManage,
+	PermAgencyAdmin,
+	PermSecurityManage,
+	PermStatsRead,
+	PermAuditRead,
+}
+
+// RolePermissions maps each role to the permissions it grants
+var RolePermissions = map[Role][]Permission{
+	RoleAdmin:  AllPermissions,
+	RoleAgent:  {PermPropertyWriteOwn},
+	RoleTenant: {PermRentalApply, PermReviewWrite},
+	// Landlords see their units; the managing agents keep operational control
+	RoleLandlord: {PermOwnerRead},
+	// Staff accounts get their access from assigned roles
+	RoleStaff:         {},
+	RoleSupport:       {PermAgentRead, PermUserRead, PermPaymentRead, PermSessionRevoke, PermStatsRead},
+	RoleModerator:     {PermAgentRead, PermPropertyWriteAny, PermPropertyModerate, PermStatsRead, PermReviewModerate},
+	RoleAgencyManager: {PermAgencyManage},
+}
+
+// AssignableRoles are the roles an admin can grant on top of a user's base role
+var AssignableRoles = []Role{RoleSupport, RoleModerator, RoleAgencyManager}
+
+// IsValid checks if the role is defined
+func (r Role) IsValid() bool {
+	_, ok := RolePermissions[r]
+	return ok
+}
+
+// IsAssignable checks if the role can be granted through role assignment
+func (r Role) IsAssignable() bool {
+	for _, role := range AssignableRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission checks if any of the roles grants the permission
+func HasPermission(roles []Role, permission Permission) bool {
+	for _, role := range roles {
+		for _, granted := range RolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PermissionsForRoles returns the distinct permissions granted by the roles
+func PermissionsForRoles(roles []Role) []Permission {
+	permissions := []Permission{}
+	for _, permission := range AllPermissions {
+		if HasPermission(roles, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// BaseRole returns the role every user of this type holds
+func (t UserType) BaseRole() Role {
+	return Role(t)
+}
+
+// UserRole represents an additional role assigned to a user
+type UserRole struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_roles_user_role"`
+	Role      Role       `json:"role" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_roles_user_role"`
+	GrantedBy *uuid.UUID `json:"granted_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// AssignRoleRequest represents the request to assign a role to a user
+type AssignRoleRequest struct {
+	Role Role `json:"role" binding:"required"`
+}
+
+// BeforeCreate GORM hook to set ID
+func (ur *UserRole) BeforeCreate(tx *gorm.DB) error {
+	if ur.ID == uuid.Nil {
+		ur.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for UserRole model
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// UserRoleRepository handles database operations for role assignments
+type UserRoleRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRoleRepository creates a new user role repository
+func NewUserRoleRepository(db *gorm.DB) *UserRoleRepository {
+	return &UserRoleRepository{db: db}
+}
+
+// GetByUserID retrieves the roles assigned to a user
+func (r *UserRoleRepository) GetByUserID(userID uuid.UUID) ([]UserRole, error) {
+	var roles []UserRole
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&roles).Error
+	return roles, err
+}
+
+// Assign grants a role to a user. Assigning a role the user already holds is a no-op.
+func (r *UserRoleRepository) Assign(userID uuid.UUID, role Role, grantedBy uuid.UUID) error {
+	userRole := &UserRole{
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: &grantedBy,
+	}
+	return r.db.Where("user_id = ? AND role = ?", userID, role).FirstOrCreate(userRole).Error
+}
+
+// Remove takes a role away from a user. It returns gorm.ErrRecordNotFound if the
+// user did not hold the role.
+func (r *UserRoleRepository) Remove(userID uuid.UUID, role Role) error {
+	result := r.db.Where("user_id = ? AND role = ?", userID, role).Delete(&UserRole{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -2,12 +2,19 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"real-estate-backend/pkg/geohash"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PropertyType represents different types of properties in Kenya
@@ -78,6 +85,8 @@ func (u *UtilitiesIncluded) Scan(value interface{}) error {
 type Property struct {
 	ID                uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	AgentID           uuid.UUID         `json:"agent_id" gorm:"type:uuid;not null"`
+	AgencyID          *uuid.UUID        `json:"agency_id,omitempty" gorm:"type:uuid;index"` // Agency whose portfolio the listing belongs to
+	OwnerID           *uuid.UUID        `json:"owner_id,omitempty" gorm:"type:uuid;index"`  // Landlord who owns the property; the agent manages it on their behalf
 	Title             string            `json:"title" gorm:"not null"`
 	Description       *string           `json:"description,omitempty"`
 	PropertyType      PropertyType      `json:"property_type" gorm:"not null;type:varchar(20)"`
@@ -91,21 +100,35 @@ type Property struct {
 	LocationDetails   *string           `json:"location_details,omitempty"`
 	Latitude          *float64          `json:"latitude,omitempty"`
 	Longitude         *float64          `json:"longitude,omitempty"`
-	Amenities         Amenities         `json:"amenities" gorm:"type:jsonb"`
+	Amenities         Amenities         `json:"amenities" gorm:"type:jsonb;index:idx_properties_amenities,type:gin"` // Catalog keys set to true, see AmenityCatalog
 	UtilitiesIncluded UtilitiesIncluded `json:"utilities_included" gorm:"type:jsonb"`
 	ParkingSpaces     int               `json:"parking_spaces"`
 	IsFurnished       bool              `json:"is_furnished" gorm:"default:false"`
-	IsAvailable       bool              `json:"is_available" gorm:"default:true"`
+	IsAvailable       bool              `json:"is_available" gorm:"default:false"` // True while published, see SetStatus
+	Status            PropertyStatus    `json:"status" gorm:"type:varchar(20);not null;default:draft;index"`
+	RejectionReason   *string           `json:"rejection_reason,omitempty"` // Why a moderator last sent the listing back
+	SubmittedAt       *time.Time        `json:"submitted_at,omitempty"`     // When the listing last entered the moderation queue
+	ReviewedBy        *uuid.UUID        `json:"reviewed_by,omitempty" gorm:"type:uuid"`
+	ReviewedAt        *time.Time        `json:"reviewed_at,omitempty"`
+	PublishedAt       *time.Time        `json:"published_at,omitempty"` // When the listing was first published
+	EditedWhileLet    bool              `json:"-" gorm:"not null;default:false"` // Key fields or photos changed while let, see PropertyHandler.relistStatus
 	AvailabilityDate  *time.Time        `json:"availability_date,omitempty"`
 	CreatedAt         time.Time         `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt         time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt         gorm.DeletedAt    `json:"-" gorm:"index"`
+	SearchVector      string            `json:"-" gorm:"type:tsvector;->:false;<-:false;index:idx_properties_search_vector,type:gin"` // Never read or written by GORM; maintained by a trigger, see migration 023
+	SearchRank        *float64          `json:"search_rank,omitempty" gorm:"->;-:migration"`    // Relevance to the search query; set only by searches
+	SearchSnippet     *string           `json:"search_snippet,omitempty" gorm:"->;-:migration"` // Matching text with the query terms in <mark> tags; set only by searches
+	DistanceKm        *float64          `json:"distance_km,omitempty" gorm:"->;-:migration"`    // Distance from the search point; set only by geo searches
+	Geohash           *string           `json:"-" gorm:"type:varchar(12);index"`                    // Full-precision geohash of the coordinates, for map clustering
 
 	// Relationships
 	County    *County         `json:"county,omitempty" gorm:"foreignKey:CountyID"`
 	SubCounty *SubCounty      `json:"sub_county,omitempty" gorm:"foreignKey:SubCountyID"`
 	Agent     *User           `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+	Agency    *Agency         `json:"agency,omitempty" gorm:"foreignKey:AgencyID"`
 	Images    []*PropertyImage `json:"images,omitempty" gorm:"foreignKey:PropertyID"`
+	Rating    *RatingSummary  `json:"rating,omitempty" gorm:"-"` // Set by handlers from the property's reviews
 }
 
 // CreatePropertyRequest represents the request to create a new property
@@ -121,13 +144,15 @@ type CreatePropertyRequest struct {
 	CountyID          int               `json:"county_id" binding:"required"`
 	SubCountyID       *int              `json:"sub_county_id,omitempty"`
 	LocationDetails   *string           `json:"location_details,omitempty"`
-	Latitude          *float64          `json:"latitude,omitempty"`
-	Longitude         *float64          `json:"longitude,omitempty"`
-	Amenities         Amenities         `json:"amenities"`
+	Latitude          *float64          `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude         *float64          `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
+	Amenities         Amenities         `json:"amenities"` // Catalog keys set to true, e.g. {"borehole": true}
 	UtilitiesIncluded UtilitiesIncluded `json:"utilities_included"`
 	ParkingSpaces     int               `json:"parking_spaces" binding:"min=0"`
 	IsFurnished       bool              `json:"is_furnished"`
 	AvailabilityDate  *time.Time        `json:"availability_date,omitempty"`
+	OwnerID           *uuid.UUID        `json:"owner_id,omitempty"` // Must be a landlord account
+	Submit            bool              `json:"submit"`             // Submit for review straight away instead of saving a draft
 }
 
 // UpdatePropertyRequest represents the request to update a property
@@ -140,14 +165,90 @@ type UpdatePropertyRequest struct {
 	RentAmount        *float64          `json:"rent_amount,omitempty" binding:"omitempty,min=0"`
 	DepositAmount     *float64          `json:"deposit_amount,omitempty" binding:"omitempty,min=0"`
 	LocationDetails   *string           `json:"location_details,omitempty"`
-	Latitude          *float64          `json:"latitude,omitempty"`
-	Longitude         *float64          `json:"longitude,omitempty"`
+	Latitude          *float64          `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90"`
+	Longitude         *float64          `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180"`
 	Amenities         *Amenities        `json:"amenities,omitempty"`
 	UtilitiesIncluded *UtilitiesIncluded `json:"utilities_included,omitempty"`
 	ParkingSpaces     *int              `json:"parking_spaces,omitempty" binding:"omitempty,min=0"`
 	IsFurnished       *bool             `json:"is_furnished,omitempty"`
-	IsAvailable       *bool             `json:"is_available,omitempty"`
+	IsAvailable       *bool             `json:"is_available,omitempty"` // Moves a published listing to let, or a let one back to published
 	AvailabilityDate  *time.Time        `json:"availability_date,omitempty"`
+	OwnerID           *uuid.UUID        `json:"owner_id,omitempty"` // Must be a landlord account
+}
+
+// GeoPoint is a WGS84 coordinate in degrees
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// GeoBounds is a map bounding box in degrees. It cannot cross the antimeridian.
+type GeoBounds struct {
+	MinLat float64 `json:"min_lat"`
+	MinLng float64 `json:"min_lng"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLng float64 `json:"max_lng"`
+}
+
+// Center returns the middle of the bounding box
+func (b *GeoBounds) Center() *GeoPoint {
+	return &GeoPoint{Lat: (b.MinLat + b.MaxLat) / 2, Lng: (b.MinLng + b.MaxLng) / 2}
+}
+
+// PropertySort names an ordering of search results
+type PropertySort string
+
+const (
+	PropertySortDefault      PropertySort = ""              // Relevance when searching text, otherwise newest
+	PropertySortRelevance    PropertySort = "relevance"     // Best text match first; needs a text query
+	PropertySortNewest       PropertySort = "newest"        // Most recently listed first
+	PropertySortPriceAsc     PropertySort = "price_asc"     // Lowest rent first
+	PropertySortPriceDesc    PropertySort = "price_desc"    // Highest rent first
+	PropertySortBedroomsAsc  PropertySort = "bedrooms_asc"  // Fewest bedrooms first
+	PropertySortBedroomsDesc PropertySort = "bedrooms_desc" // Most bedrooms first
+	PropertySortDistance     PropertySort = "distance"      // Nearest first; needs a point or bounding box
+)
+
+// ErrInvalidCursor is returned when a page cursor is malformed or was issued for another sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PropertyPage is a page of property search results
+type PropertyPage struct {
+	Properties []*Property `json:"properties"`
+	Total      int64       `json:"total"`       // Matches across all pages
+	NextCursor *string     `json:"next_cursor"` // Cursor of the next page; nil on the last page
+}
+
+// earthRadiusKm is the mean radius of the Earth used for distances
+const earthRadiusKm = 6371.0
+
+// haversineSQL is the great-circle distance in km from the point bound to its
+// placeholders (lat, lat, lng) to the property's coordinates
+var haversineSQL = fmt.Sprintf("2 * %g * asin(least(1, sqrt("+
+	"power(sin(radians(latitude - ?) / 2), 2) + "+
+	"cos(radians(?)) * cos(radians(latitude)) * power(sin(radians(longitude - ?) / 2), 2))))", earthRadiusKm)
+
+// PropertyCluster aggregates the listings in one geohash cell of a map
+type PropertyCluster struct {
+	Geohash   string            `json:"geohash"`
+	Count     int64             `json:"count"`
+	Latitude  float64           `json:"latitude"` // Centroid of the listings
+	Longitude float64           `json:"longitude"`
+	MinRent   float64           `json:"min_rent"`
+	MaxRent   float64           `json:"max_rent"`
+	Listings  []*PropertyMarker `json:"listings,omitempty" gorm:"-"` // Set only when the cell holds few listings
+}
+
+// PropertyMarker is the part of a listing a map pin shows
+type PropertyMarker struct {
+	ID           uuid.UUID    `json:"id"`
+	Title        string       `json:"title"`
+	PropertyType PropertyType `json:"property_type"`
+	Bedrooms     int          `json:"bedrooms"`
+	RentAmount   float64      `json:"rent_amount"`
+	Latitude     float64      `json:"latitude"`
+	Longitude    float64      `json:"longitude"`
+	Geohash      string       `json:"-"` // Cell of the marker's cluster
 }
 
 // PropertySearchFilters represents search filters for properties
@@ -162,16 +263,39 @@ type PropertySearchFilters struct {
 	MinBathrooms     *int          `json:"min_bathrooms,omitempty"`
 	IsFurnished      *bool         `json:"is_furnished,omitempty"`
 	HasParkingSpaces *bool         `json:"has_parking_spaces,omitempty"`
+	Amenities        []string      `json:"amenities,omitempty"` // Catalog keys the property must have, all of them
 	IsAvailable      *bool         `json:"is_available,omitempty"`
+	Status           *PropertyStatus `json:"status,omitempty"`
+	Query            string        `json:"q,omitempty"` // Full-text search over title, description, location and county names
+	Near             *GeoPoint     `json:"near,omitempty"`      // Point distances are measured from
+	RadiusKm         *float64      `json:"radius_km,omitempty"` // Only properties within this distance of Near
+	Bounds           *GeoBounds    `json:"bounds,omitempty"`    // Only properties inside this box; distances are measured from its center unless Near is set
+	Sort             PropertySort  `json:"sort,omitempty"`
+	AgentID          *uuid.UUID    `json:"-"` // Only the listings of this agent
+	Cursor           string        `json:"-"` // Continue after the page that returned this cursor; replaces Offset
+	HideSuspendedAgents bool       `json:"-"` // Leave out listings of suspended agents
 	Limit            int           `json:"limit,omitempty"`
 	Offset           int           `json:"offset,omitempty"`
 }
 
-// BeforeCreate GORM hook to set ID
+// BeforeCreate GORM hook to set ID and start new listings as drafts
 func (p *Property) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
 	}
+	if p.Status == "" {
+		p.Status = PropertyStatusDraft
+	}
+	return nil
+}
+
+// BeforeSave GORM hook to keep the geohash in step with the coordinates
+func (p *Property) BeforeSave(tx *gorm.DB) error {
+	p.Geohash = nil
+	if p.Latitude != nil && p.Longitude != nil {
+		hash := geohash.Encode(*p.Latitude, *p.Longitude, geohash.MaxPrecision)
+		p.Geohash = &hash
+	}
 	return nil
 }
 
@@ -198,17 +322,35 @@ func (r *PropertyRepository) Create(property *Property) error {
 // GetByID retrieves a property by ID
 func (r *PropertyRepository) GetByID(id uuid.UUID) (*Property, error) {
 	var property Property
-	err := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Images").First(&property, "id = ?", id).Error
+	err := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").First(&property, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
+
+// GetPublishedByID retrieves a published listing whose agent is not suspended,
+// the listings the public can see
+func (r *PropertyRepository) GetPublishedByID(id uuid.UUID) (*Property, error) {
+	var property Property
+	err := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").
+		Where("status = ? AND agent_id NOT IN (?)", PropertyStatusPublished, r.suspendedAgentIDs()).
+		First(&property, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &property, nil
 }
 
+// suspendedAgentIDs is a subquery of the IDs of suspended agents
+func (r *PropertyRepository) suspendedAgentIDs() *gorm.DB {
+	return r.db.Model(&User{}).Select("id").Where("is_suspended = ?", true)
+}
+
 // GetByAgentID retrieves properties by agent ID
 func (r *PropertyRepository) GetByAgentID(agentID uuid.UUID, limit, offset int) ([]*Property, error) {
 	var properties []*Property
-	query := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Images").Where("agent_id = ?", agentID).Order("created_at DESC")
+	query := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").Where("agent_id = ?", agentID).Order("created_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -222,9 +364,68 @@ func (r *PropertyRepository) GetByAgentID(agentID uuid.UUID, limit, offset int)
 	return properties, result.Error
 }
 
-// Update updates a property
+// CountByAgentID counts the properties an agent manages
+func (r *PropertyRepository) CountByAgentID(agentID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&Property{}).Where("agent_id = ?", agentID).Count(&count).Error
+	return count, err
+}
+
+// GetByAgencyID retrieves the listings in an agency's portfolio
+func (r *PropertyRepository) GetByAgencyID(agencyID uuid.UUID, limit, offset int) ([]*Property, error) {
+	var properties []*Property
+	query := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").Where("agency_id = ?", agencyID).Order("created_at DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	result := query.Find(&properties)
+	return properties, result.Error
+}
+
+// GetByOwnerID retrieves the properties a landlord owns
+func (r *PropertyRepository) GetByOwnerID(ownerID uuid.UUID, limit, offset int) ([]*Property, error) {
+	var properties []*Property
+	query := r.db.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").Where("owner_id = ?", ownerID).Order("created_at DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	result := query.Find(&properties)
+	return properties, result.Error
+}
+
+// Update saves a property's fields. Its lifecycle fields are saved only by
+// UpdateStatus and UpdateWithStatus, and preloaded relationships are not saved.
 func (r *PropertyRepository) Update(property *Property) error {
-	return r.db.Save(property).Error
+	return saveProperty(r.db, property)
+}
+
+func saveProperty(db *gorm.DB, property *Property) error {
+	return db.Omit(append([]string{clause.Associations}, propertyLifecycleColumns...)...).Save(property).Error
+}
+
+// Reassign moves a listing of the agency to another agent, returning
+// gorm.ErrRecordNotFound if the listing is not, or no longer, the agency's
+func (r *PropertyRepository) Reassign(propertyID, agencyID, agentID uuid.UUID) error {
+	result := r.db.Model(&Property{}).Where("id = ? AND agency_id = ?", propertyID, agencyID).Update("agent_id", agentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 // Delete deletes a property
@@ -232,10 +433,254 @@ func (r *PropertyRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&Property{}, id).Error
 }
 
-// Search searches for properties based on filters
-func (r *PropertyRepository) Search(filters *PropertySearchFilters) ([]*Property, error) {
-	var properties []*Property
-	query := r.db.Model(&Property{}).Preload("County").Preload("SubCounty").Preload("Agent").Preload("Images")
+// Search searches for properties based on filters, returning a page of results
+// with the total number of matches and the cursor of the next page
+func (r *PropertyRepository) Search(filters *PropertySearchFilters) (*PropertyPage, error) {
+	page := &PropertyPage{}
+	if err := r.applyFilters(r.db.Model(&Property{}), filters).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	query := r.db.Model(&Property{}).Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images")
+	query = r.applyFilters(query, filters)
+
+	columns := []string{"properties.*"}
+	var columnArgs []interface{}
+
+	// Full-text search ranks matches by relevance and highlights the matching text
+	if filters.Query != "" {
+		columns = append(columns,
+			"ts_rank_cd(search_vector, websearch_to_tsquery('english', ?)) AS search_rank",
+			"ts_headline('english', concat_ws(' — ', title, description, location_details), websearch_to_tsquery('english', ?), "+
+				"'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS search_snippet")
+		columnArgs = append(columnArgs, filters.Query, filters.Query)
+	}
+
+	// Geo search reports the distance of each property
+	if origin := filters.distanceOrigin(); origin != nil {
+		columns = append(columns, haversineSQL+" AS distance_km")
+		columnArgs = append(columnArgs, origin.Lat, origin.Lat, origin.Lng)
+	}
+
+	if len(columns) > 1 {
+		query = query.Select(strings.Join(columns, ", "), columnArgs...)
+	}
+
+	sort := filters.resolvedSort()
+	keys := filters.sortKeys(sort)
+	for _, key := range keys {
+		if key.desc {
+			query = query.Order(key.order + " DESC")
+		} else {
+			query = query.Order(key.order)
+		}
+	}
+
+	// Set default limit if not provided
+	limit := 20
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+
+	// A cursor continues after the last result of the previous page, so listings
+	// added or removed meanwhile do not shift the results
+	if filters.Cursor != "" {
+		values, err := decodePropertyCursor(filters.Cursor, sort, keys)
+		if err != nil {
+			return nil, err
+		}
+		condition, args := keysetCondition(keys, values)
+		query = query.Where(condition, args...)
+	} else if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	// Fetch one extra result to learn whether there is a next page
+	if err := query.Limit(limit + 1).Find(&page.Properties).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Properties) > limit {
+		page.Properties = page.Properties[:limit]
+		cursor, err := encodePropertyCursor(sort, keys, page.Properties[limit-1])
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &cursor
+	}
+	return page, nil
+}
+
+// resolvedSort returns the sort of the results, choosing one when none was asked for
+func (f *PropertySearchFilters) resolvedSort() PropertySort {
+	switch {
+	case f.Sort == PropertySortRelevance && f.Query == "",
+		f.Sort == PropertySortDistance && f.distanceOrigin() == nil:
+		return PropertySortNewest
+	case f.Sort != PropertySortDefault:
+		return f.Sort
+	case f.Query != "":
+		return PropertySortRelevance
+	default:
+		return PropertySortNewest
+	}
+}
+
+// sortKey is one of the columns search results are ordered by
+type sortKey struct {
+	order string        // Column or select alias to order by
+	expr  string        // SQL computing the key, for keyset conditions
+	args  []interface{} // Values for the placeholders of expr
+	desc  bool
+	value func(p *Property) interface{}                  // The key of a result, stored in cursors
+	parse func(raw json.RawMessage) (interface{}, error) // Reads a key back from a cursor
+}
+
+// sortKeys returns the keys of the sort. Every sort ends with created_at and id,
+// so the order is total and cursors are unambiguous.
+func (f *PropertySearchFilters) sortKeys(sort PropertySort) []sortKey {
+	column := func(name string, desc bool, value func(p *Property) interface{}, parse func(json.RawMessage) (interface{}, error)) sortKey {
+		return sortKey{order: name, expr: name, desc: desc, value: value, parse: parse}
+	}
+	rent := func(desc bool) sortKey {
+		return column("rent_amount", desc, func(p *Property) interface{} { return p.RentAmount }, parseCursorValue[float64])
+	}
+	bedrooms := func(desc bool) sortKey {
+		return column("bedrooms", desc, func(p *Property) interface{} { return p.Bedrooms }, parseCursorValue[int])
+	}
+	keys := []sortKey{
+		column("created_at", true, func(p *Property) interface{} { return p.CreatedAt }, parseCursorValue[time.Time]),
+		column("id", true, func(p *Property) interface{} { return p.ID }, parseCursorValue[uuid.UUID]),
+	}
+
+	switch sort {
+	case PropertySortRelevance:
+		rank := sortKey{
+			order: "search_rank",
+			expr:  "ts_rank_cd(search_vector, websearch_to_tsquery('english', ?))",
+			args:  []interface{}{f.Query},
+			desc:  true,
+			value: func(p *Property) interface{} { return derefFloat(p.SearchRank) },
+			parse: parseCursorValue[float64],
+		}
+		return append([]sortKey{rank}, keys...)
+	case PropertySortDistance:
+		origin := f.distanceOrigin()
+		distance := sortKey{
+			order: "distance_km",
+			expr:  haversineSQL,
+			args:  []interface{}{origin.Lat, origin.Lat, origin.Lng},
+			value: func(p *Property) interface{} { return derefFloat(p.DistanceKm) },
+			parse: parseCursorValue[float64],
+		}
+		return append([]sortKey{distance}, keys...)
+	case PropertySortPriceAsc:
+		return append([]sortKey{rent(false)}, keys...)
+	case PropertySortPriceDesc:
+		return append([]sortKey{rent(true)}, keys...)
+	case PropertySortBedroomsAsc:
+		return append([]sortKey{bedrooms(false)}, keys...)
+	case PropertySortBedroomsDesc:
+		return append([]sortKey{bedrooms(true)}, keys...)
+	default:
+		return keys
+	}
+}
+
+// keysetCondition returns the condition matching the results that come after the
+// one with the given keys: those greater in the first key, or equal in it and
+// greater in the next, and so on, where greater follows each key's direction
+func keysetCondition(keys []sortKey, values []interface{}) (string, []interface{}) {
+	clauses := make([]string, 0, len(keys))
+	var args []interface{}
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].expr+" = ?")
+			args = append(append(args, keys[j].args...), values[j])
+		}
+		op := " > ?"
+		if key.desc {
+			op = " < ?"
+		}
+		parts = append(parts, key.expr+op)
+		args = append(append(args, key.args...), values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+// propertyCursor is the decoded form of a page cursor
+type propertyCursor struct {
+	Sort   PropertySort      `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// encodePropertyCursor returns the opaque cursor of the page after the result
+func encodePropertyCursor(sort PropertySort, keys []sortKey, last *Property) (string, error) {
+	cursor := propertyCursor{Sort: sort}
+	for _, key := range keys {
+		raw, err := json.Marshal(key.value(last))
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, raw)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePropertyCursor reads the keys back from a cursor issued for the same sort
+func decodePropertyCursor(encoded string, sort PropertySort, keys []sortKey) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor propertyCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || len(cursor.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if values[i], err = key.parse(cursor.Values[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// parseCursorValue reads a key of type T from a cursor
+func parseCursorValue[T any](raw json.RawMessage) (interface{}, error) {
+	var value T
+	err := json.Unmarshal(raw, &value)
+	return value, err
+}
+
+// derefFloat returns the value of a computed column, or 0 if it was not selected
+func derefFloat(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// distanceOrigin returns the point distances are measured from: Near, or the
+// center of Bounds. It is nil unless the search is by location.
+func (f *PropertySearchFilters) distanceOrigin() *GeoPoint {
+	if f.Near == nil && f.Bounds != nil {
+		return f.Bounds.Center()
+	}
+	return f.Near
+}
+
+// applyFilters adds the conditions of the filters to a query on properties
+func (r *PropertyRepository) applyFilters(query *gorm.DB, filters *PropertySearchFilters) *gorm.DB {
+	if filters.AgentID != nil {
+		query = query.Where("agent_id = ?", *filters.AgentID)
+	}
 
 	if filters.CountyID != nil {
 		query = query.Where("county_id = ?", *filters.CountyID)
@@ -281,19 +726,131 @@ func (r *PropertyRepository) Search(filters *PropertySearchFilters) ([]*Property
 		query = query.Where("is_available = ?", *filters.IsAvailable)
 	}
 
-	query = query.Order("created_at DESC")
+	if filters.Status != nil {
+		query = query.Where("status = ?", *filters.Status)
+	}
+
+	// Containment is answered from the GIN index on amenities
+	if len(filters.Amenities) > 0 {
+		required := make(map[string]bool, len(filters.Amenities))
+		for _, key := range filters.Amenities {
+			required[key] = true
+		}
+		if containment, err := json.Marshal(required); err == nil {
+			query = query.Where("amenities @> ?::jsonb", string(containment))
+		}
+	}
 
-	// Set default limit if not provided
-	limit := 20
-	if filters.Limit > 0 {
-		limit = filters.Limit
+	if filters.HideSuspendedAgents {
+		query = query.Where("agent_id NOT IN (?)", r.suspendedAgentIDs())
 	}
-	query = query.Limit(limit)
 
-	if filters.Offset > 0 {
-		query = query.Offset(filters.Offset)
+	if filters.Query != "" {
+		query = query.Where("search_vector @@ websearch_to_tsquery('english', ?)", filters.Query)
+	}
+
+	// Geo search leaves out properties without coordinates
+	if filters.distanceOrigin() != nil {
+		query = query.Where("latitude IS NOT NULL AND longitude IS NOT NULL")
+	}
+	if filters.Near != nil && filters.RadiusKm != nil {
+		// Narrow to the enclosing box first so the latitude/longitude index applies
+		latDelta := *filters.RadiusKm / earthRadiusKm * 180 / math.Pi
+		query = query.Where("latitude BETWEEN ? AND ?", filters.Near.Lat-latDelta, filters.Near.Lat+latDelta)
+		if cosLat := math.Cos(filters.Near.Lat * math.Pi / 180); cosLat > 0.01 {
+			if lngDelta := latDelta / cosLat; lngDelta < 180 {
+				query = query.Where("longitude BETWEEN ? AND ?", filters.Near.Lng-lngDelta, filters.Near.Lng+lngDelta)
+			}
+		}
+		query = query.Where(haversineSQL+" <= ?", filters.Near.Lat, filters.Near.Lat, filters.Near.Lng, *filters.RadiusKm)
+	}
+	if filters.Bounds != nil {
+		query = query.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
+			filters.Bounds.MinLat, filters.Bounds.MaxLat, filters.Bounds.MinLng, filters.Bounds.MaxLng)
 	}
 
-	err := query.Find(&properties).Error
-	return properties, err
+	return query
+}
+
+// Cluster groups the properties matching the filters by geohash cells of the given
+// precision. Cells holding at most maxListings properties also list them.
+func (r *PropertyRepository) Cluster(filters *PropertySearchFilters, precision, maxListings int) ([]*PropertyCluster, error) {
+	var clusters []*PropertyCluster
+	err := r.applyFilters(r.db.Model(&Property{}), filters).
+		Select("left(geohash, ?) AS geohash, count(*) AS count, "+
+			"avg(latitude)::float8 AS latitude, avg(longitude)::float8 AS longitude, "+
+			"min(rent_amount)::float8 AS min_rent, max(rent_amount)::float8 AS max_rent", precision).
+		Where("geohash IS NOT NULL").
+		Group("1").
+		Order("1").
+		Scan(&clusters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byCell := make(map[string]*PropertyCluster)
+	var cells []string
+	for _, cluster := range clusters {
+		if cluster.Count <= int64(maxListings) {
+			byCell[cluster.Geohash] = cluster
+			cells = append(cells, cluster.Geohash)
+		}
+	}
+	if len(cells) == 0 {
+		return clusters, nil
+	}
+
+	var markers []*PropertyMarker
+	err = r.applyFilters(r.db.Model(&Property{}), filters).
+		Select("id, title, property_type, bedrooms, rent_amount::float8 AS rent_amount, "+
+			"latitude::float8 AS latitude, longitude::float8 AS longitude, left(geohash, ?) AS geohash", precision).
+		Where("left(geohash, ?) IN ?", precision, cells).
+		Order("created_at DESC").
+		Scan(&markers).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, marker := range markers {
+		if cluster, ok := byCell[marker.Geohash]; ok {
+			cluster.Listings = append(cluster.Listings, marker)
+		}
+	}
+	return clusters, nil
+}
+
+// CheckSearchTrigger returns an error if the trigger of migration 023 that keeps
+// search_vector current is missing, in which case search would miss listings
+func (r *PropertyRepository) CheckSearchTrigger() error {
+	var count int64
+	err := r.db.Raw("SELECT COUNT(*) FROM pg_trigger WHERE tgname = ? AND tgrelid = 'properties'::regclass",
+		"properties_search_vector").Scan(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("trigger properties_search_vector is missing; apply migrations/023_add_property_search.sql")
+	}
+	return nil
+}
+
+// BackfillGeohashes sets the geohash of properties saved with coordinates before
+// geohashes were recorded. It returns how many properties it updated.
+func (r *PropertyRepository) BackfillGeohashes() (int, error) {
+	updated := 0
+	for {
+		var properties []*Property
+		err := r.db.Select("id, latitude, longitude").
+			Where("geohash IS NULL AND latitude IS NOT NULL AND longitude IS NOT NULL").
+			Limit(500).Find(&properties).Error
+		if err != nil || len(properties) == 0 {
+			return updated, err
+		}
+		for _, property := range properties {
+			hash := geohash.Encode(*property.Latitude, *property.Longitude, geohash.MaxPrecision)
+			if err := r.db.Model(&Property{}).Where("id = ?", property.ID).UpdateColumn("geohash", hash).Error; err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
 }
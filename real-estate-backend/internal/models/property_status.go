@@ -0,0 +1,191 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PropertyStatus is the stage of a listing's lifecycle
+type PropertyStatus string
+
+const (
+	PropertyStatusDraft         PropertyStatus = "draft"          // Being prepared by the agent; not public
+	PropertyStatusPendingReview PropertyStatus = "pending_review" // Submitted and waiting in the moderation queue
+	PropertyStatusPublished     PropertyStatus = "published"      // Approved and shown in public listings
+	PropertyStatusLet           PropertyStatus = "let"            // Taken by a tenant; hidden until relisted
+	PropertyStatusArchived      PropertyStatus = "archived"       // Withdrawn by the agent
+)
+
+// PropertyStatuses lists the statuses in lifecycle order
+var PropertyStatuses = []PropertyStatus{
+	PropertyStatusDraft,
+	PropertyStatusPendingReview,
+	PropertyStatusPublished,
+	PropertyStatusLet,
+	PropertyStatusArchived,
+}
+
+// agentTransitions maps each status to the statuses an agent can move a listing to.
+// Only moderators publish a listing for the first time, see Approve and Reject.
+var agentTransitions = map[PropertyStatus][]PropertyStatus{
+	PropertyStatusDraft:         {PropertyStatusPendingReview, PropertyStatusArchived},
+	PropertyStatusPendingReview: {PropertyStatusDraft, PropertyStatusArchived},
+	PropertyStatusPublished:     {PropertyStatusLet, PropertyStatusArchived, PropertyStatusDraft},
+	PropertyStatusLet:           {PropertyStatusPublished, PropertyStatusArchived},
+	PropertyStatusArchived:      {PropertyStatusDraft},
+}
+
+// propertyLifecycleColumns are written only through status changes, so that an edit
+// saved from a stale copy cannot undo a moderator's decision
+var propertyLifecycleColumns = []string{
+	"status", "is_available", "rejection_reason", "submitted_at", "reviewed_by", "reviewed_at", "published_at",
+	"edited_while_let",
+}
+
+// ErrPropertyStatusChanged is returned when a listing's status changed since it was loaded
+var ErrPropertyStatusChanged = errors.New("property status changed")
+
+// IsValid checks if the status is defined
+func (s PropertyStatus) IsValid() bool {
+	_, ok := agentTransitions[s]
+	return ok
+}
+
+// AgentTransitions returns the statuses an agent can move a listing to from s
+func (s PropertyStatus) AgentTransitions() []PropertyStatus {
+	return agentTransitions[s]
+}
+
+// CanAgentMoveTo checks if an agent can move a listing from s to the status
+func (s PropertyStatus) CanAgentMoveTo(status PropertyStatus) bool {
+	for _, allowed := range agentTransitions[s] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReject checks if a moderator can send a listing in s back to its agent
+func (s PropertyStatus) CanReject() bool {
+	return s == PropertyStatusPendingReview || s == PropertyStatusPublished
+}
+
+// UpdatePropertyStatusRequest represents an agent's change of a listing's status
+type UpdatePropertyStatusRequest struct {
+	Status PropertyStatus `json:"status" binding:"required,oneof=draft pending_review published let archived"`
+}
+
+// RejectPropertyRequest represents a moderator's rejection of a listing
+type RejectPropertyRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// SetStatus moves the listing to the status, keeping IsAvailable, EditedWhileLet
+// and the lifecycle timestamps in step. It does not check the transition.
+func (p *Property) SetStatus(status PropertyStatus) {
+	now := time.Now()
+	switch status {
+	case PropertyStatusPendingReview:
+		p.SubmittedAt = &now
+	case PropertyStatusPublished:
+		if p.PublishedAt == nil {
+			p.PublishedAt = &now
+		}
+		p.EditedWhileLet = false
+	}
+	p.Status = status
+	p.IsAvailable = status == PropertyStatusPublished
+}
+
+// Approve publishes a listing waiting for review
+func (p *Property) Approve(moderatorID uuid.UUID) {
+	now := time.Now()
+	p.SetStatus(PropertyStatusPublished)
+	p.RejectionReason = nil
+	p.ReviewedBy = &moderatorID
+	p.ReviewedAt = &now
+}
+
+// Reject sends a listing back to draft with the reason shown to its agent
+func (p *Property) Reject(moderatorID uuid.UUID, reason string) {
+	now := time.Now()
+	p.SetStatus(PropertyStatusDraft)
+	p.RejectionReason = &reason
+	p.ReviewedBy = &moderatorID
+	p.ReviewedAt = &now
+}
+
+// ChangesReviewedFields reports whether the update changes a field moderators
+// check before a listing is published: its description, price, size or location
+func (req *UpdatePropertyRequest) ChangesReviewedFields(p *Property) bool {
+	return (req.Title != nil && *req.Title != p.Title) ||
+		changesString(req.Description, p.Description) ||
+		(req.Bedrooms != nil && *req.Bedrooms != p.Bedrooms) ||
+		(req.Bathrooms != nil && *req.Bathrooms != p.Bathrooms) ||
+		(req.RentAmount != nil && *req.RentAmount != p.RentAmount) ||
+		changesFloat(req.DepositAmount, p.DepositAmount) ||
+		changesString(req.LocationDetails, p.LocationDetails) ||
+		changesFloat(req.Latitude, p.Latitude) ||
+		changesFloat(req.Longitude, p.Longitude)
+}
+
+func changesString(update, current *string) bool {
+	return update != nil && (current == nil || *update != *current)
+}
+
+func changesFloat(update, current *float64) bool {
+	return update != nil && (current == nil || *update != *current)
+}
+
+// UpdateStatus saves the lifecycle fields of the listing if its status is still
+// from, returning ErrPropertyStatusChanged otherwise
+func (r *PropertyRepository) UpdateStatus(property *Property, from PropertyStatus) error {
+	return updatePropertyStatus(r.db, property, from)
+}
+
+// UpdateWithStatus saves the listing's fields and its status change from status
+// from in one transaction, returning ErrPropertyStatusChanged if the status moved
+// meanwhile
+func (r *PropertyRepository) UpdateWithStatus(property *Property, from PropertyStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updatePropertyStatus(tx, property, from); err != nil {
+			return err
+		}
+		return saveProperty(tx, property)
+	})
+}
+
+func updatePropertyStatus(db *gorm.DB, property *Property, from PropertyStatus) error {
+	result := db.Model(property).Where("status = ?", from).
+		Select(append(propertyLifecycleColumns, "updated_at")).
+		Updates(property)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPropertyStatusChanged
+	}
+	return nil
+}
+
+// GetPendingReview returns the moderation queue, longest waiting first, with the
+// total number of listings waiting
+func (r *PropertyRepository) GetPendingReview(limit, offset int) ([]*Property, int64, error) {
+	query := r.db.Model(&Property{}).Where("status = ?", PropertyStatusPendingReview)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var properties []*Property
+	err := query.Preload("County").Preload("SubCounty").Preload("Agent").Preload("Agency").Preload("Images").
+		Order("submitted_at ASC, id ASC").
+		Limit(limit).Offset(offset).
+		Find(&properties).Error
+	return properties, total, err
+}
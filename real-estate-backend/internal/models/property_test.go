@@ -0,0 +1,201 @@
+package models
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const rankSQL = "ts_rank_cd(search_vector, websearch_to_tsquery('english', ?))"
+
+func TestPropertySearchFiltersResolvedSort(t *testing.T) {
+	near := &GeoPoint{Lat: -1.2864, Lng: 36.8172}
+	bounds := &GeoBounds{MinLat: -1.3, MinLng: 36.7, MaxLat: -1.2, MaxLng: 36.9}
+
+	tests := []struct {
+		name    string
+		filters PropertySearchFilters
+		want    PropertySort
+	}{
+		{"default is newest", PropertySearchFilters{}, PropertySortNewest},
+		{"default with a query is relevance", PropertySearchFilters{Query: "garden"}, PropertySortRelevance},
+		{"relevance without a query is newest", PropertySearchFilters{Sort: PropertySortRelevance}, PropertySortNewest},
+		{"distance without a point is newest", PropertySearchFilters{Sort: PropertySortDistance}, PropertySortNewest},
+		{"distance from a point", PropertySearchFilters{Sort: PropertySortDistance, Near: near}, PropertySortDistance},
+		{"distance from a bounding box", PropertySearchFilters{Sort: PropertySortDistance, Bounds: bounds}, PropertySortDistance},
+		{"explicit sort is kept", PropertySearchFilters{Sort: PropertySortPriceDesc, Query: "garden"}, PropertySortPriceDesc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.resolvedSort(); got != tt.want {
+				t.Errorf("resolvedSort() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropertyCursorRoundTrip(t *testing.T) {
+	rank, distance := 0.4375, 2.918
+	last := &Property{
+		ID:         uuid.MustParse("0b6f2a8e-3c1d-4e5f-9a7b-1c2d3e4f5a6b"),
+		CreatedAt:  time.Date(2026, 5, 14, 8, 45, 12, 345678000, time.UTC),
+		RentAmount: 47500.5,
+		Bedrooms:   3,
+		SearchRank: &rank,
+		DistanceKm: &distance,
+	}
+	filters := &PropertySearchFilters{
+		Query: "garden",
+		Near:  &GeoPoint{Lat: -1.2864, Lng: 36.8172},
+	}
+
+	tests := []struct {
+		sort PropertySort
+		want []interface{}
+	}{
+		{PropertySortNewest, []interface{}{last.CreatedAt, last.ID}},
+		{PropertySortRelevance, []interface{}{rank, last.CreatedAt, last.ID}},
+		{PropertySortDistance, []interface{}{distance, last.CreatedAt, last.ID}},
+		{PropertySortPriceAsc, []interface{}{last.RentAmount, last.CreatedAt, last.ID}},
+		{PropertySortPriceDesc, []interface{}{last.RentAmount, last.CreatedAt, last.ID}},
+		{PropertySortBedroomsAsc, []interface{}{last.Bedrooms, last.CreatedAt, last.ID}},
+		{PropertySortBedroomsDesc, []interface{}{last.Bedrooms, last.CreatedAt, last.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			keys := filters.sortKeys(tt.sort)
+			cursor, err := encodePropertyCursor(tt.sort, keys, last)
+			if err != nil {
+				t.Fatalf("encodePropertyCursor() error = %v", err)
+			}
+			values, err := decodePropertyCursor(cursor, tt.sort, keys)
+			if err != nil {
+				t.Fatalf("decodePropertyCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("decodePropertyCursor() = %#v, want %#v", values, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePropertyCursorInvalid(t *testing.T) {
+	filters := &PropertySearchFilters{}
+	keys := filters.sortKeys(PropertySortPriceAsc)
+	valid, err := encodePropertyCursor(PropertySortPriceAsc, keys, &Property{ID: uuid.New(), CreatedAt: time.Now().UTC(), RentAmount: 30000})
+	if err != nil {
+		t.Fatalf("encodePropertyCursor() error = %v", err)
+	}
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   PropertySort
+	}{
+		{"not base64", "not a cursor!", PropertySortPriceAsc},
+		{"not json", encode("price_asc"), PropertySortPriceAsc},
+		{"issued for another sort", valid, PropertySortPriceDesc},
+		{"too few values", encode(`{"s":"price_asc","v":[30000,"2026-05-14T08:45:12Z"]}`), PropertySortPriceAsc},
+		{"value of the wrong type", encode(`{"s":"price_asc","v":["cheap","2026-05-14T08:45:12Z","0b6f2a8e-3c1d-4e5f-9a7b-1c2d3e4f5a6b"]}`), PropertySortPriceAsc},
+		{"malformed id", encode(`{"s":"price_asc","v":[30000,"2026-05-14T08:45:12Z","42"]}`), PropertySortPriceAsc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodePropertyCursor(tt.cursor, tt.sort, filters.sortKeys(tt.sort)); err != ErrInvalidCursor {
+				t.Errorf("decodePropertyCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	created := time.Date(2026, 5, 14, 8, 45, 12, 0, time.UTC)
+	id := uuid.MustParse("0b6f2a8e-3c1d-4e5f-9a7b-1c2d3e4f5a6b")
+	origin := &GeoPoint{Lat: -1.2864, Lng: 36.8172}
+	filters := &PropertySearchFilters{Query: "garden", Near: origin}
+
+	tests := []struct {
+		sort     PropertySort
+		first    interface{} // Value of the leading key, if the sort has one
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			sort:     PropertySortNewest,
+			wantSQL:  "((created_at < ?) OR (created_at = ? AND id < ?))",
+			wantArgs: []interface{}{created, created, id},
+		},
+		{
+			sort:  PropertySortRelevance,
+			first: 0.5,
+			wantSQL: "((" + rankSQL + " < ?) OR " +
+				"(" + rankSQL + " = ? AND created_at < ?) OR " +
+				"(" + rankSQL + " = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{
+				"garden", 0.5,
+				"garden", 0.5, created,
+				"garden", 0.5, created, id,
+			},
+		},
+		{
+			sort:  PropertySortDistance,
+			first: 2.5,
+			wantSQL: "((" + haversineSQL + " > ?) OR " +
+				"(" + haversineSQL + " = ? AND created_at < ?) OR " +
+				"(" + haversineSQL + " = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{
+				origin.Lat, origin.Lat, origin.Lng, 2.5,
+				origin.Lat, origin.Lat, origin.Lng, 2.5, created,
+				origin.Lat, origin.Lat, origin.Lng, 2.5, created, id,
+			},
+		},
+		{
+			sort:     PropertySortPriceAsc,
+			first:    30000.0,
+			wantSQL:  "((rent_amount > ?) OR (rent_amount = ? AND created_at < ?) OR (rent_amount = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{30000.0, 30000.0, created, 30000.0, created, id},
+		},
+		{
+			sort:     PropertySortPriceDesc,
+			first:    30000.0,
+			wantSQL:  "((rent_amount < ?) OR (rent_amount = ? AND created_at < ?) OR (rent_amount = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{30000.0, 30000.0, created, 30000.0, created, id},
+		},
+		{
+			sort:     PropertySortBedroomsAsc,
+			first:    2,
+			wantSQL:  "((bedrooms > ?) OR (bedrooms = ? AND created_at < ?) OR (bedrooms = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{2, 2, created, 2, created, id},
+		},
+		{
+			sort:     PropertySortBedroomsDesc,
+			first:    2,
+			wantSQL:  "((bedrooms < ?) OR (bedrooms = ? AND created_at < ?) OR (bedrooms = ? AND created_at = ? AND id < ?))",
+			wantArgs: []interface{}{2, 2, created, 2, created, id},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			values := []interface{}{created, id}
+			if tt.first != nil {
+				values = append([]interface{}{tt.first}, values...)
+			}
+
+			gotSQL, gotArgs := keysetCondition(filters.sortKeys(tt.sort), values)
+			if gotSQL != tt.wantSQL {
+				t.Errorf("keysetCondition() SQL =\n%s\nwant\n%s", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("keysetCondition() args = %#v, want %#v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
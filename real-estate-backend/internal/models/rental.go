@@ -124,7 +124,7 @@ type Lease struct {
 	ID           uuid.UUID   `json:"id" db:"id"`
 	PropertyID   uuid.UUID   `json:"property_id" db:"property_id"`
 	TenantID     uuid.UUID   `json:"tenant_id" db:"tenant_id"`
-	LandlordID   uuid.UUID   `json:"landlord_id" db:"landlord_id"`
+	LandlordID   uuid.UUID   `json:"landlord_id" db:"landlord_id"` // Party the lease was signed with; owners see leases through properties.owner_id
 	StartDate    time.Time   `json:"start_date" db:"start_date"`
 	EndDate      time.Time   `json:"end_date" db:"end_date"`
 	MonthlyRent  float64     `json:"monthly_rent" db:"monthly_rent"`
@@ -420,6 +420,49 @@ func (r *LeaseRepository) GetByTenantID(tenantID uuid.UUID) ([]*Lease, error) {
 	return leases, nil
 }
 
+// GetByOwnerID retrieves the leases on the properties a landlord currently owns.
+// Ownership is read from properties.owner_id, so it follows changes of owner.
+func (r *LeaseRepository) GetByOwnerID(ownerID uuid.UUID) ([]*Lease, error) {
+	query := `
+		SELECT l.id, l.property_id, l.tenant_id, l.landlord_id, l.start_date, l.end_date,
+			   l.monthly_rent, l.deposit_paid, l.status, l.lease_terms, l.created_at, l.updated_at
+		FROM leases l
+		JOIN properties pr ON pr.id = l.property_id
+		WHERE pr.owner_id = $1
+		ORDER BY l.created_at DESC`
+
+	rows, err := r.db.Query(query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leases := []*Lease{}
+	for rows.Next() {
+		lease := &Lease{}
+		err := rows.Scan(
+			&lease.ID,
+			&lease.PropertyID,
+			&lease.TenantID,
+			&lease.LandlordID,
+			&lease.StartDate,
+			&lease.EndDate,
+			&lease.MonthlyRent,
+			&lease.DepositPaid,
+			&lease.Status,
+			&lease.LeaseTerms,
+			&lease.CreatedAt,
+			&lease.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		leases = append(leases, lease)
+	}
+
+	return leases, rows.Err()
+}
+
 // PaymentRepository handles database operations for payments
 type PaymentRepository struct {
 	db *sql.DB
@@ -502,3 +545,114 @@ func (r *PaymentRepository) GetByLeaseID(leaseID uuid.UUID) ([]*Payment, error)
 	return payments, nil
 }
 
+// GetByOwnerID retrieves payments on the leases of the properties a landlord owns
+// made between from and to
+func (r *PaymentRepository) GetByOwnerID(ownerID uuid.UUID, from, to time.Time) ([]*Payment, error) {
+	query := `
+		SELECT p.id, p.lease_id, p.amount, p.payment_type, p.payment_method, p.mpesa_transaction_id,
+			   p.payment_date, p.due_date, p.status, p.notes, p.created_at
+		FROM payments p
+		JOIN leases l ON l.id = p.lease_id
+		JOIN properties pr ON pr.id = l.property_id
+		WHERE pr.owner_id = $1 AND p.payment_date >= $2 AND p.payment_date < $3
+		ORDER BY p.payment_date DESC`
+
+	rows, err := r.db.Query(query, ownerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*Payment{}
+	for rows.Next() {
+		payment := &Payment{}
+		err := rows.Scan(
+			&payment.ID,
+			&payment.LeaseID,
+			&payment.Amount,
+			&payment.PaymentType,
+			&payment.PaymentMethod,
+			&payment.MPesaTransactionID,
+			&payment.PaymentDate,
+			&payment.DueDate,
+			&payment.Status,
+			&payment.Notes,
+			&payment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// OwnerStatement summarises the completed payments on a landlord's properties
+// between From and To
+type OwnerStatement struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"` // Exclusive
+	Properties []PropertyStatement `json:"properties"`
+	Total      float64             `json:"total"`
+}
+
+// PropertyStatement totals the completed payments on one property by payment type
+type PropertyStatement struct {
+	PropertyID  uuid.UUID `json:"property_id"`
+	Title       string    `json:"title"`
+	AgentID     uuid.UUID `json:"agent_id"`
+	Payments    int64     `json:"payments"`
+	Rent        float64   `json:"rent"`
+	Deposit     float64   `json:"deposit"`
+	Utility     float64   `json:"utility"`
+	Maintenance float64   `json:"maintenance"`
+	Total       float64   `json:"total"`
+}
+
+// GetOwnerStatement totals the completed payments on the properties a landlord owns
+// per property between from and to
+func (r *PaymentRepository) GetOwnerStatement(ownerID uuid.UUID, from, to time.Time) (*OwnerStatement, error) {
+	query := `
+		SELECT pr.id, pr.title, pr.agent_id, COUNT(*),
+			   COALESCE(SUM(p.amount) FILTER (WHERE p.payment_type = 'rent'), 0),
+			   COALESCE(SUM(p.amount) FILTER (WHERE p.payment_type = 'deposit'), 0),
+			   COALESCE(SUM(p.amount) FILTER (WHERE p.payment_type = 'utility'), 0),
+			   COALESCE(SUM(p.amount) FILTER (WHERE p.payment_type = 'maintenance'), 0),
+			   SUM(p.amount)
+		FROM payments p
+		JOIN leases l ON l.id = p.lease_id
+		JOIN properties pr ON pr.id = l.property_id
+		WHERE pr.owner_id = $1 AND p.status = $2 AND p.payment_date >= $3 AND p.payment_date < $4
+		GROUP BY pr.id, pr.title, pr.agent_id
+		ORDER BY pr.title`
+
+	rows, err := r.db.Query(query, ownerID, PaymentStatusCompleted, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statement := &OwnerStatement{From: from, To: to, Properties: []PropertyStatement{}}
+	for rows.Next() {
+		var property PropertyStatement
+		err := rows.Scan(
+			&property.PropertyID,
+			&property.Title,
+			&property.AgentID,
+			&property.Payments,
+			&property.Rent,
+			&property.Deposit,
+			&property.Utility,
+			&property.Maintenance,
+			&property.Total,
+		)
+		if err != nil {
+			return nil, err
+		}
+		statement.Properties = append(statement.Properties, property)
+		statement.Total += property.Total
+	}
+
+	return statement, rows.Err()
+}
@@ -0,0 +1,486 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewTargetType names what a review rates
+type ReviewTargetType string
+
+const (
+	ReviewTargetProperty ReviewTargetType = "property"
+	ReviewTargetAgent    ReviewTargetType = "agent"
+)
+
+// ReviewSubScores lists the aspects each kind of review scores from 1 to 5
+var ReviewSubScores = map[ReviewTargetType][]string{
+	ReviewTargetProperty: {"condition", "value", "neighbourhood"},
+	ReviewTargetAgent:    {"communication", "responsiveness", "professionalism"},
+}
+
+// ReviewStatus represents the moderation state of a review
+type ReviewStatus string
+
+const (
+	ReviewStatusPublished ReviewStatus = "published"
+	ReviewStatusFlagged   ReviewStatus = "flagged" // Reported and waiting for moderation; still shown
+	ReviewStatusRemoved   ReviewStatus = "removed" // Hidden by a moderator
+)
+
+var (
+	// ErrReviewExists is returned when a tenant reviews the same property or agent twice
+	ErrReviewExists = errors.New("review already exists")
+	// ErrReviewReplied is returned when an agent replies to a review a second time
+	ErrReviewReplied = errors.New("review already has a reply")
+	// ErrReviewReported is returned when a user reports the same review twice
+	ErrReviewReported = errors.New("review already reported by this user")
+)
+
+// ReviewScores maps sub-score names to scores, stored as JSON
+type ReviewScores map[string]int
+
+// Value implements the driver.Valuer interface for database storage
+func (s ReviewScores) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (s *ReviewScores) Scan(value interface{}) error {
+	if value == nil {
+		*s = make(ReviewScores)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into ReviewScores", value)
+	}
+}
+
+// Review is a tenant's rating of a property they rented or an agent they dealt with
+type Review struct {
+	ID              uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TargetType      ReviewTargetType `json:"target_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_reviews_tenant_target;index:idx_reviews_target"`
+	TargetID        uuid.UUID        `json:"target_id" gorm:"type:uuid;not null;uniqueIndex:idx_reviews_tenant_target;index:idx_reviews_target"` // Property or agent reviewed
+	TenantID        uuid.UUID        `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_reviews_tenant_target"`
+	AgentID         uuid.UUID        `json:"agent_id" gorm:"type:uuid;not null;index"` // Agent who may reply: the one reviewed, or the property's agent
+	Rating          int              `json:"rating" gorm:"not null"`
+	Scores          ReviewScores     `json:"scores" gorm:"type:jsonb;not null"`
+	Comment         *string          `json:"comment,omitempty"`
+	Status          ReviewStatus     `json:"-" gorm:"type:varchar(20);not null;default:'published';index"`
+	ReportCount     int              `json:"-" gorm:"not null;default:0"`
+	Reply           *string          `json:"reply,omitempty"`
+	RepliedAt       *time.Time       `json:"replied_at,omitempty"`
+	ModeratedBy     *uuid.UUID       `json:"-" gorm:"type:uuid"`
+	ModeratedAt     *time.Time       `json:"-"`
+	ModerationNotes *string          `json:"-"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Tenant *User `json:"-" gorm:"foreignKey:TenantID"`
+}
+
+// ReviewResponse is a review as shown publicly, naming the tenant by first name and initial
+type ReviewResponse struct {
+	*Review
+	Reviewer string `json:"reviewer"`
+}
+
+// ToResponse converts a review with its tenant preloaded to a ReviewResponse
+func (r *Review) ToResponse() *ReviewResponse {
+	response := &ReviewResponse{Review: r, Reviewer: "Tenant"}
+	if r.Tenant != nil && r.Tenant.FirstName != "" {
+		response.Reviewer = r.Tenant.FirstName
+		if r.Tenant.LastName != "" {
+			response.Reviewer += " " + string([]rune(r.Tenant.LastName)[:1]) + "."
+		}
+	}
+	return response
+}
+
+// ModerationReview is a review as shown in the moderation queue
+type ModerationReview struct {
+	*Review
+	TenantID        uuid.UUID      `json:"tenant_id"`
+	Status          ReviewStatus   `json:"status"`
+	ReportCount     int            `json:"report_count"`
+	ModerationNotes *string        `json:"moderation_notes,omitempty"`
+	Reports         []ReviewReport `json:"reports"`
+}
+
+// ReviewReport is a user's report of an inappropriate review
+type ReviewReport struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ReviewID   uuid.UUID  `json:"review_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_reports_review_reporter"`
+	ReporterID uuid.UUID  `json:"reporter_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_reports_review_reporter"`
+	Reason     string     `json:"reason" gorm:"type:text;not null"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"` // Set when a moderator settles the review
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// CreateReviewRequest represents the request to review a property or agent
+type CreateReviewRequest struct {
+	Rating  int          `json:"rating" binding:"required,min=1,max=5"`
+	Scores  ReviewScores `json:"scores" binding:"required"` // Every sub-score of the target type, each from 1 to 5
+	Comment *string      `json:"comment,omitempty" binding:"omitempty,max=2000"`
+}
+
+// Validate checks that the scores are exactly the sub-scores of the target type
+func (r *CreateReviewRequest) Validate(targetType ReviewTargetType) error {
+	names := ReviewSubScores[targetType]
+	if len(r.Scores) != len(names) {
+		return fmt.Errorf("scores must contain %v", names)
+	}
+	for _, name := range names {
+		score, ok := r.Scores[name]
+		if !ok {
+			return fmt.Errorf("scores must contain %v", names)
+		}
+		if score < 1 || score > 5 {
+			return fmt.Errorf("score %s must be between 1 and 5", name)
+		}
+	}
+	return nil
+}
+
+// ReplyToReviewRequest represents an agent's public reply to a review
+type ReplyToReviewRequest struct {
+	Reply string `json:"reply" binding:"required,max=2000"`
+}
+
+// ReportReviewRequest represents the request to report a review for moderation
+type ReportReviewRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// ModerateReviewRequest represents a moderator's decision on a reported review
+type ModerateReviewRequest struct {
+	Action string `json:"action" binding:"required,oneof=keep remove"`
+	Notes  string `json:"notes" binding:"max=1000"`
+}
+
+// RatingSummary aggregates the visible reviews of a property or agent
+type RatingSummary struct {
+	Average float64            `json:"average"`
+	Count   int64              `json:"count"`
+	Scores  map[string]float64 `json:"scores"` // Average of each sub-score
+}
+
+// BeforeCreate GORM hook to set ID
+func (r *Review) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for Review model
+func (Review) TableName() string {
+	return "reviews"
+}
+
+// BeforeCreate GORM hook to set ID
+func (r *ReviewReport) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for ReviewReport model
+func (ReviewReport) TableName() string {
+	return "review_reports"
+}
+
+// ReviewRepository handles database operations for reviews
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new review repository
+func NewReviewRepository(db *gorm.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+// HasLeaseOnProperty checks if the tenant has ever held a lease on the property
+func (r *ReviewRepository) HasLeaseOnProperty(tenantID, propertyID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.Raw("SELECT EXISTS (SELECT 1 FROM leases WHERE tenant_id = ? AND property_id = ?)",
+		tenantID, propertyID).Scan(&exists).Error
+	return exists, err
+}
+
+// HasLeaseWithAgent checks if the tenant has ever held a lease on a property the agent manages
+func (r *ReviewRepository) HasLeaseWithAgent(tenantID, agentID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.Raw(`SELECT EXISTS (
+			SELECT 1 FROM leases JOIN properties ON properties.id = leases.property_id
+			WHERE leases.tenant_id = ? AND properties.agent_id = ?)`,
+		tenantID, agentID).Scan(&exists).Error
+	return exists, err
+}
+
+// Create creates a review, returning ErrReviewExists if the tenant already reviewed the target
+func (r *ReviewRepository) Create(review *Review) error {
+	var count int64
+	err := r.db.Model(&Review{}).
+		Where("tenant_id = ? AND target_type = ? AND target_id = ?", review.TenantID, review.TargetType, review.TargetID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrReviewExists
+	}
+
+	// The unique index catches a second review saved concurrently after the count
+	err = r.db.Omit(clause.Associations).Create(review).Error
+	if isUniqueViolation(err) {
+		return ErrReviewExists
+	}
+	return err
+}
+
+// GetByID retrieves a review by ID
+func (r *ReviewRepository) GetByID(id uuid.UUID) (*Review, error) {
+	var review Review
+	if err := r.db.Preload("Tenant").First(&review, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetForTarget retrieves the visible reviews of a property or agent, newest first,
+// with the total count
+func (r *ReviewRepository) GetForTarget(targetType ReviewTargetType, targetID uuid.UUID, limit, offset int) ([]Review, int64, error) {
+	query := r.db.Model(&Review{}).
+		Where("target_type = ? AND target_id = ? AND status <> ?", targetType, targetID, ReviewStatusRemoved)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	reviews := []Review{}
+	err := query.Preload("Tenant").Order("created_at DESC").Limit(limit).Offset(offset).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// SetReply records the agent's reply, returning ErrReviewReplied if there already is one
+func (r *ReviewRepository) SetReply(review *Review, reply string) error {
+	now := time.Now()
+	result := r.db.Model(&Review{}).Where("id = ? AND reply IS NULL", review.ID).
+		Updates(map[string]interface{}{"reply": reply, "replied_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReviewReplied
+	}
+	review.Reply = &reply
+	review.RepliedAt = &now
+	return nil
+}
+
+// Report records a report and sends a published review to moderation, returning
+// ErrReviewReported if the user already reported it
+func (r *ReviewRepository) Report(reviewID, reporterID uuid.UUID, reason string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&ReviewReport{}).Where("review_id = ? AND reporter_id = ?", reviewID, reporterID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrReviewReported
+		}
+
+		report := &ReviewReport{ReviewID: reviewID, ReporterID: reporterID, Reason: reason}
+		if err := tx.Create(report).Error; err != nil {
+			if isUniqueViolation(err) {
+				return ErrReviewReported
+			}
+			return err
+		}
+
+		// A removed review stays removed; a kept one goes back to moderation
+		return tx.Model(&Review{}).Where("id = ?", reviewID).Updates(map[string]interface{}{
+			"report_count": gorm.Expr("report_count + 1"),
+			"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+				ReviewStatusPublished, ReviewStatusFlagged),
+		}).Error
+	})
+}
+
+// GetFlagged retrieves the reviews waiting for moderation, most reported first,
+// with their unresolved reports and the total count
+func (r *ReviewRepository) GetFlagged(limit, offset int) ([]ModerationReview, int64, error) {
+	query := r.db.Model(&Review{}).Where("status = ?", ReviewStatusFlagged)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []Review
+	err := query.Order("report_count DESC, created_at").Limit(limit).Offset(offset).Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, len(reviews))
+	for i := range reviews {
+		ids[i] = reviews[i].ID
+	}
+	var reports []ReviewReport
+	if len(ids) > 0 {
+		err = r.db.Where("review_id IN ? AND resolved_at IS NULL", ids).Order("created_at").Find(&reports).Error
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	byReview := make(map[uuid.UUID][]ReviewReport, len(reviews))
+	for _, report := range reports {
+		byReview[report.ReviewID] = append(byReview[report.ReviewID], report)
+	}
+
+	queue := make([]ModerationReview, len(reviews))
+	for i := range reviews {
+		queue[i] = ModerationReview{
+			Review:          &reviews[i],
+			TenantID:        reviews[i].TenantID,
+			Status:          reviews[i].Status,
+			ReportCount:     reviews[i].ReportCount,
+			ModerationNotes: reviews[i].ModerationNotes,
+			Reports:         byReview[reviews[i].ID],
+		}
+		if queue[i].Reports == nil {
+			queue[i].Reports = []ReviewReport{}
+		}
+	}
+	return queue, total, nil
+}
+
+// Moderate keeps or removes a review and resolves its open reports
+func (r *ReviewRepository) Moderate(review *Review, moderatorID uuid.UUID, keep bool, notes string) error {
+	status := ReviewStatusRemoved
+	if keep {
+		status = ReviewStatusPublished
+	}
+	now := time.Now()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":           status,
+			"moderated_by":     moderatorID,
+			"moderated_at":     now,
+			"moderation_notes": nil,
+		}
+		if notes != "" {
+			updates["moderation_notes"] = notes
+		}
+		if err := tx.Model(&Review{}).Where("id = ?", review.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&ReviewReport{}).Where("review_id = ? AND resolved_at IS NULL", review.ID).
+			Update("resolved_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		review.Status = status
+		review.ModeratedBy = &moderatorID
+		review.ModeratedAt = &now
+		review.ModerationNotes = nil
+		if notes != "" {
+			review.ModerationNotes = &notes
+		}
+		return nil
+	})
+}
+
+// GetSummaries aggregates the visible reviews of each target. Targets without
+// reviews are left out of the result.
+func (r *ReviewRepository) GetSummaries(targetType ReviewTargetType, targetIDs []uuid.UUID) (map[uuid.UUID]*RatingSummary, error) {
+	summaries := make(map[uuid.UUID]*RatingSummary, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return summaries, nil
+	}
+
+	var overall []struct {
+		TargetID uuid.UUID
+		Average  float64
+		Count    int64
+	}
+	err := r.db.Model(&Review{}).Select("target_id, AVG(rating) AS average, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ? AND status <> ?", targetType, targetIDs, ReviewStatusRemoved).
+		Group("target_id").Scan(&overall).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range overall {
+		summaries[row.TargetID] = &RatingSummary{
+			Average: row.Average,
+			Count:   row.Count,
+			Scores:  make(map[string]float64),
+		}
+	}
+	if len(summaries) == 0 {
+		return summaries, nil
+	}
+
+	var scores []struct {
+		TargetID uuid.UUID
+		Name     string
+		Average  float64
+	}
+	err = r.db.Raw(`
+		SELECT reviews.target_id, score.key AS name, AVG(score.value::numeric) AS average
+		FROM reviews, jsonb_each_text(reviews.scores) AS score
+		WHERE reviews.target_type = ? AND reviews.target_id IN ? AND reviews.status <> ?
+		GROUP BY reviews.target_id, score.key`,
+		targetType, targetIDs, ReviewStatusRemoved,
+	).Scan(&scores).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range scores {
+		if summary, ok := summaries[row.TargetID]; ok {
+			summary.Scores[row.Name] = row.Average
+		}
+	}
+	return summaries, nil
+}
+
+// GetSummary aggregates the visible reviews of one target; the count is zero if it has none
+func (r *ReviewRepository) GetSummary(targetType ReviewTargetType, targetID uuid.UUID) (*RatingSummary, error) {
+	summaries, err := r.GetSummaries(targetType, []uuid.UUID{targetID})
+	if err != nil {
+		return nil, err
+	}
+	if summary, ok := summaries[targetID]; ok {
+		return summary, nil
+	}
+	return &RatingSummary{Scores: map[string]float64{}}, nil
+}
+
+// isUniqueViolation checks if err is Postgres rejecting a row that breaks a unique index
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
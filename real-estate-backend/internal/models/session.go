@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrSessionAlreadyRotated is returned when a refresh token has already been exchanged
+var ErrSessionAlreadyRotated = errors.New("session has already been rotated")
+
+// UserSession represents a refresh-token session for a user.
+// Every refresh rotates the session: the old row is revoked and points at its
+// replacement, and all rows created from the same login share a FamilyID.
+type UserSession struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID   uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uuid.UUID `json:"-" gorm:"type:uuid"`
+	UserAgent  *string    `json:"user_agent,omitempty" gorm:"type:text"`
+	IPAddress  *string    `json:"ip_address,omitempty" gorm:"type:varchar(45)"`
+	MFA        bool       `json:"mfa" gorm:"not null;default:false"` // Established with a second factor
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// RefreshTokenRequest represents a request carrying a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// BeforeCreate GORM hook to set ID
+func (s *UserSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.FamilyID == uuid.Nil {
+		s.FamilyID = s.ID
+	}
+	return nil
+}
+
+// TableName returns the table name for UserSession model
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// IsExpired checks if the session has expired
+func (s *UserSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// IsRevoked checks if the session has been revoked
+func (s *UserSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// WasRotated checks if the session was revoked because it was exchanged for a new one
+func (s *UserSession) WasRotated() bool {
+	return s.ReplacedBy != nil
+}
+
+// UserSessionRepository handles database operations for user sessions
+type UserSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository creates a new user session repository
+func NewUserSessionRepository(db *gorm.DB) *UserSessionRepository {
+	return &UserSessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *UserSessionRepository) Create(session *UserSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByTokenHash retrieves a session by the hash of its refresh token
+func (r *UserSessionRepository) GetByTokenHash(tokenHash string) (*UserSession, error) {
+	var session UserSession
+	err := r.db.Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Rotate revokes the current session and stores its replacement atomically.
+// It returns ErrSessionAlreadyRotated if another request rotated the session first.
+func (r *UserSessionRepository) Rotate(current *UserSession, next *UserSession) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		next.MFA = current.MFA
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&UserSession{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "replaced_by": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionAlreadyRotated
+		}
+		return nil
+	})
+}
+
+// RevokeFamily revokes every session descended from the same login
+func (r *UserSessionRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.db.Model(&UserSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// GetActiveByUserID retrieves all active sessions for a user
+func (r *UserSessionRepository) GetActiveByUserID(userID uuid.UUID) ([]*UserSession, error) {
+	var sessions []*UserSession
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteExpired deletes sessions that expired more than a day ago and returns how
+// many it deleted. Rotated sessions are kept until then so that reuse of an old
+// refresh token is still detected.
+func (r *UserSessionRepository) DeleteExpired() (int64, error) {
+	result := r.db.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&UserSession{})
+	return result.RowsAffected, result.Error
+}
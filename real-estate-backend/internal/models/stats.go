@@ -0,0 +1,242 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatsIntervals lists the periods signups can be grouped by
+var StatsIntervals = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+// AdminStats represents the aggregates shown on the admin dashboard. User and
+// listing counts describe the current state; signups and listing changes cover
+// the requested date range.
+type AdminStats struct {
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"` // Exclusive
+	Users       UserStats     `json:"users"`
+	Signups     []PeriodCount `json:"signups"`
+	Listings    ListingStats  `json:"listings"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// UserStats counts active users by type and by verification and approval state
+type UserStats struct {
+	Total         int64              `json:"total"`
+	Deactivated   int64              `json:"deactivated"`
+	ByType        map[UserType]int64 `json:"by_type"`
+	EmailVerified int64              `json:"email_verified"`
+	PhoneVerified int64              `json:"phone_verified"`
+	Agents        AgentStats         `json:"agents"`
+}
+
+// AgentStats counts agents by approval state
+type AgentStats struct {
+	Pending   int64 `json:"pending"` // Waiting for approval, as listed by GetPendingAgents
+	Approved  int64 `json:"approved"`
+	Rejected  int64 `json:"rejected"`
+	Suspended int64 `json:"suspended"`
+	Revoked   int64 `json:"revoked"`
+}
+
+// PeriodCount is the number of events in the period starting at Period
+type PeriodCount struct {
+	Period time.Time `json:"period"`
+	Count  int64     `json:"count"`
+}
+
+// ListingStats describes active listings and how the listings changed each week
+type ListingStats struct {
+	Active        int64                  `json:"active"`
+	PendingReview int64                  `json:"pending_review"` // Listings waiting in the moderation queue
+	ByType        map[PropertyType]int64 `json:"by_type"`
+	ByCounty      []CountyListingStats   `json:"by_county"`
+	Weekly        []WeeklyListingChanges `json:"weekly"`
+}
+
+// CountyListingStats describes the active listings of a county
+type CountyListingStats struct {
+	CountyID    int     `json:"county_id"`
+	CountyName  string  `json:"county_name"`
+	Listings    int64   `json:"listings"`
+	AverageRent float64 `json:"average_rent"`
+	MedianRent  float64 `json:"median_rent"`
+}
+
+// WeeklyListingChanges counts the listings added and removed in the week starting on WeekStart
+type WeeklyListingChanges struct {
+	WeekStart time.Time `json:"week_start"`
+	Added     int64     `json:"added"`
+	Removed   int64     `json:"removed"`
+}
+
+// StatsRepository computes dashboard aggregates
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// GetAdminStats computes the dashboard aggregates. Signups are grouped by interval,
+// which must be a key of StatsIntervals.
+func (r *StatsRepository) GetAdminStats(from, to time.Time, interval string) (*AdminStats, error) {
+	stats := &AdminStats{From: from, To: to, GeneratedAt: time.Now()}
+
+	var err error
+	if stats.Users, err = r.userStats(); err != nil {
+		return nil, err
+	}
+	if stats.Signups, err = r.signups(from, to, interval); err != nil {
+		return nil, err
+	}
+	if stats.Listings, err = r.listingStats(from, to); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// userStats counts users in a single pass over the users table
+func (r *StatsRepository) userStats() (UserStats, error) {
+	var stats UserStats
+	err := r.db.Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE is_active),
+			COUNT(*) FILTER (WHERE NOT is_active),
+			COUNT(*) FILTER (WHERE is_active AND is_verified),
+			COUNT(*) FILTER (WHERE is_active AND is_phone_verified),
+			COUNT(*) FILTER (WHERE is_active AND user_type = @agent AND is_verified AND NOT is_approved
+				AND rejected_at IS NULL AND revoked_at IS NULL),
+			COUNT(*) FILTER (WHERE is_active AND user_type = @agent AND is_approved),
+			COUNT(*) FILTER (WHERE is_active AND user_type = @agent AND NOT is_approved AND rejected_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE is_active AND user_type = @agent AND is_suspended),
+			COUNT(*) FILTER (WHERE is_active AND user_type = @agent AND NOT is_approved AND revoked_at IS NOT NULL)
+		FROM users
+		WHERE deleted_at IS NULL`,
+		sql.Named("agent", UserTypeAgent),
+	).Row().Scan(
+		&stats.Total, &stats.Deactivated, &stats.EmailVerified, &stats.PhoneVerified,
+		&stats.Agents.Pending, &stats.Agents.Approved, &stats.Agents.Rejected,
+		&stats.Agents.Suspended, &stats.Agents.Revoked,
+	)
+	if err != nil {
+		return stats, err
+	}
+
+	var byType []struct {
+		UserType UserType
+		Count    int64
+	}
+	err = r.db.Model(&User{}).Select("user_type, COUNT(*) AS count").
+		Where("is_active = ?", true).Group("user_type").Scan(&byType).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.ByType = make(map[UserType]int64, len(byType))
+	for _, row := range byType {
+		stats.ByType[row.UserType] = row.Count
+	}
+	return stats, nil
+}
+
+// signups counts new accounts per period between from and to
+func (r *StatsRepository) signups(from, to time.Time, interval string) ([]PeriodCount, error) {
+	signups := []PeriodCount{}
+	err := r.db.Model(&User{}).Select("date_trunc(?, created_at) AS period, COUNT(*) AS count", interval).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("period").Order("period").Scan(&signups).Error
+	return signups, err
+}
+
+// listingStats describes active listings and the weekly listing changes between from and to
+func (r *StatsRepository) listingStats(from, to time.Time) (ListingStats, error) {
+	stats := ListingStats{ByCounty: []CountyListingStats{}}
+
+	var byType []struct {
+		PropertyType PropertyType
+		Count        int64
+	}
+	err := r.db.Model(&Property{}).Select("property_type, COUNT(*) AS count").
+		Where("status = ?", PropertyStatusPublished).Group("property_type").Scan(&byType).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.ByType = make(map[PropertyType]int64, len(byType))
+	for _, row := range byType {
+		stats.ByType[row.PropertyType] = row.Count
+		stats.Active += row.Count
+	}
+
+	err = r.db.Model(&Property{}).Where("status = ?", PropertyStatusPendingReview).Count(&stats.PendingReview).Error
+	if err != nil {
+		return stats, err
+	}
+
+	err = r.db.Model(&Property{}).
+		Select(`properties.county_id, counties.name AS county_name, COUNT(*) AS listings,
+			AVG(properties.rent_amount) AS average_rent,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY properties.rent_amount) AS median_rent`).
+		Joins("JOIN counties ON counties.id = properties.county_id").
+		Where("properties.status = ?", PropertyStatusPublished).
+		Group("properties.county_id, counties.name").
+		Order("listings DESC, county_name").
+		Scan(&stats.ByCounty).Error
+	if err != nil {
+		return stats, err
+	}
+
+	// Deleted listings count as added in the week they were created, so query
+	// the soft-deleted rows too
+	var added, removed []PeriodCount
+	err = r.db.Unscoped().Model(&Property{}).Select("date_trunc('week', created_at) AS period, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("period").Scan(&added).Error
+	if err != nil {
+		return stats, err
+	}
+	err = r.db.Unscoped().Model(&Property{}).Select("date_trunc('week', deleted_at) AS period, COUNT(*) AS count").
+		Where("deleted_at >= ? AND deleted_at < ?", from, to).
+		Group("period").Scan(&removed).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.Weekly = mergeWeeklyChanges(added, removed)
+	return stats, nil
+}
+
+// mergeWeeklyChanges combines the weekly added and removed counts, oldest week first
+func mergeWeeklyChanges(added, removed []PeriodCount) []WeeklyListingChanges {
+	weeks := make(map[time.Time]*WeeklyListingChanges)
+	week := func(start time.Time) *WeeklyListingChanges {
+		if w, ok := weeks[start]; ok {
+			return w
+		}
+		w := &WeeklyListingChanges{WeekStart: start}
+		weeks[start] = w
+		return w
+	}
+	for _, row := range added {
+		week(row.Period).Added = row.Count
+	}
+	for _, row := range removed {
+		week(row.Period).Removed = row.Count
+	}
+
+	changes := make([]WeeklyListingChanges, 0, len(weeks))
+	for _, w := range weeks {
+		changes = append(changes, *w)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].WeekStart.Before(changes[j].WeekStart)
+	})
+	return changes
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets when enabling 2FA
+const recoveryCodeCount = 10
+
+// TwoFactorRecoveryCode is a single-use code that replaces a TOTP code when the
+// user has lost their authenticator. Only the SHA-256 hash is stored.
+type TwoFactorRecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TwoFactorPolicy records whether a role must use two-factor authentication
+type TwoFactorPolicy struct {
+	Role      Role       `json:"role" gorm:"type:varchar(50);primaryKey"`
+	Required  bool       `json:"required" gorm:"not null;default:false"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TwoFactorCodeRequest represents a request carrying a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest represents the request to turn off two-factor authentication
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+// TwoFactorLoginRequest represents the second step of a two-factor login
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+// SetTwoFactorPolicyRequest represents the request to require 2FA for a role
+type SetTwoFactorPolicyRequest struct {
+	Role     Role `json:"role" binding:"required"`
+	Required bool `json:"required"`
+}
+
+// BeforeCreate GORM hook to set ID
+func (rc *TwoFactorRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if rc.ID == uuid.Nil {
+		rc.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName returns the table name for TwoFactorRecoveryCode model
+func (TwoFactorRecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
+
+// TableName returns the table name for TwoFactorPolicy model
+func (TwoFactorPolicy) TableName() string {
+	return "two_factor_policies"
+}
+
+// hashRecoveryCode normalises a recovery code and returns its SHA-256 hash
+func hashRecoveryCode(code string) string {
+	normalised := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, 5)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(bytes)
+	return code[:5] + "-" + code[5:], nil
+}
+
+// TwoFactorRepository handles database operations for recovery codes and 2FA policies
+type TwoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// ReplaceRecoveryCodes deletes a user's recovery codes and generates a new set.
+// The plain codes are returned once and never stored.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]TwoFactorRecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, TwoFactorRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ConsumeRecoveryCode marks a matching unused recovery code as used. It returns
+// false if the code does not match any unused code of the user.
+func (r *TwoFactorRepository) ConsumeRecoveryCode(userID uuid.UUID, code string) (bool, error) {
+	result := r.db.Model(&TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a user has left
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&TwoFactorRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteRecoveryCodes deletes all recovery codes of a user
+func (r *TwoFactorRepository) DeleteRecoveryCodes(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&TwoFactorRecoveryCode{}).Error
+}
+
+// GetPolicies retrieves the 2FA policy of every role that has one
+func (r *TwoFactorRepository) GetPolicies() ([]TwoFactorPolicy, error) {
+	var policies []TwoFactorPolicy
+	err := r.db.Order("role").Find(&policies).Error
+	return policies, err
+}
+
+// SetPolicy creates or updates the 2FA policy of a role
+func (r *TwoFactorRepository) SetPolicy(role Role, required bool, updatedBy uuid.UUID) error {
+	policy := TwoFactorPolicy{Role: role, Required: required, UpdatedBy: &updatedBy}
+	return r.db.Save(&policy).Error
+}
+
+// IsRequired checks if any of the roles must use two-factor authentication
+func (r *TwoFactorRepository) IsRequired(roles []Role) (bool, error) {
+	var count int64
+	err := r.db.Model(&TwoFactorPolicy{}).Where("role IN ? AND required = ?", roles, true).Count(&count).Error
+	return count > 0, err
+}
@@ -1,12 +1,15 @@
 package models
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserType represents the type of user
@@ -16,29 +19,57 @@ const (
 	UserTypeAdmin  UserType = "admin"
 	UserTypeTenant UserType = "tenant"
 	UserTypeAgent  UserType = "agent"
+	UserTypeStaff  UserType = "staff"
+	// UserTypeLandlord owns properties that agents list and manage on their behalf
+	UserTypeLandlord UserType = "landlord"
 )
 
+// ErrInvalidAgentTransition is returned when an agent lifecycle action does not
+// apply to the agent's current state, e.g. suspending an agent who is not approved
+var ErrInvalidAgentTransition = errors.New("action does not apply to the agent's current state")
+
 // User represents a user in the system
 type User struct {
-	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	Email           string     `json:"email" gorm:"uniqueIndex;not null"`
-	PasswordHash    string     `json:"-" gorm:"not null"`
-	FirstName       string     `json:"first_name" gorm:"not null"`
-	LastName        string     `json:"last_name" gorm:"not null"`
-	PhoneNumber     string     `json:"phone_number" gorm:"uniqueIndex;not null"`
-	UserType        UserType   `json:"user_type" gorm:"not null;type:varchar(20)"`
-	ProfileImageURL *string    `json:"profile_image_url,omitempty"`
-	IsVerified      bool       `json:"is_verified" gorm:"default:false"`
-	IsApproved      bool       `json:"is_approved" gorm:"default:false"` // For agent approval by admin
-	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
-	ApprovedBy      *uuid.UUID `json:"approved_by,omitempty"` // Admin who approved
-	IsActive        bool       `json:"is_active" gorm:"default:true"`
-	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
-	
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Email            string         `json:"email" gorm:"uniqueIndex;not null"`
+	PasswordHash     string         `json:"-" gorm:"not null"`
+	FirstName        string         `json:"first_name" gorm:"not null"`
+	LastName         string         `json:"last_name" gorm:"not null"`
+	PhoneNumber      string         `json:"phone_number" gorm:"uniqueIndex;not null"`
+	UserType         UserType       `json:"user_type" gorm:"not null;type:varchar(20)"`
+	ProfileImageURL  *string        `json:"profile_image_url,omitempty"`
+	ProfileImageID   *string        `json:"-" gorm:"column:profile_image_public_id"` // Cloudinary public ID of the profile photo
+	IsVerified       bool           `json:"is_verified" gorm:"default:false"`
+	IsPhoneVerified  bool           `json:"is_phone_verified" gorm:"not null;default:false"`
+	PhoneVerifiedAt  *time.Time     `json:"phone_verified_at,omitempty"`
+	IsApproved       bool           `json:"is_approved" gorm:"default:false"` // For agent approval by admin
+	ApprovedAt       *time.Time     `json:"approved_at,omitempty"`
+	ApprovedBy       *uuid.UUID     `json:"approved_by,omitempty"` // Admin who approved
+	RejectedAt       *time.Time     `json:"rejected_at,omitempty"`
+	RejectedBy       *uuid.UUID     `json:"-"` // Admin who rejected the application
+	RejectionReason  *string        `json:"-"`
+	IsSuspended      bool           `json:"is_suspended" gorm:"not null;default:false"` // Suspended agents cannot manage listings and their listings are hidden
+	SuspendedAt      *time.Time     `json:"suspended_at,omitempty"`
+	SuspendedBy      *uuid.UUID     `json:"-"`
+	SuspensionReason *string        `json:"-"`
+	RevokedAt        *time.Time     `json:"revoked_at,omitempty"` // When approval was last revoked
+	RevokedBy        *uuid.UUID     `json:"-"`
+	RevocationReason *string        `json:"-"`
+	ReinstatedAt     *time.Time     `json:"reinstated_at,omitempty"`
+	ReinstatedBy     *uuid.UUID     `json:"-"`
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	AgencyID         *uuid.UUID     `json:"agency_id,omitempty" gorm:"type:uuid;index"`   // Agency the agent works for
+	TokenVersion     int            `json:"-" gorm:"not null;default:0;<-:create"`        // Bumped to invalidate every issued token
+	TOTPSecret       *string        `json:"-" gorm:"column:totp_secret;type:varchar(64)"` // Set during enrollment, before 2FA is enabled
+	TwoFactorEnabled bool           `json:"two_factor_enabled" gorm:"not null;default:false"`
+	TOTPLastStep     int64          `json:"-" gorm:"column:totp_last_step;not null;default:0;<-:create"` // Last accepted TOTP time step, to stop code replay
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
 	// Relationships
 	Properties []Property `json:"properties,omitempty" gorm:"foreignKey:AgentID"`
+	Agency     *Agency    `json:"agency,omitempty" gorm:"foreignKey:AgencyID"`
 }
 
 // CreateUserRequest represents the request to create a new user
@@ -48,10 +79,17 @@ type CreateUserRequest struct {
 	FirstName   string   `json:"first_name" binding:"required"`
 	LastName    string   `json:"last_name" binding:"required"`
 	PhoneNumber string   `json:"phone_number" binding:"required"`
-	UserType    UserType `json:"user_type" binding:"required"`
+	UserType    UserType `json:"user_type" binding:"required,oneof=tenant agent"` // Admin, staff and landlord accounts are created by invitation
 	IDNumber    *string  `json:"id_number,omitempty"`
 }
 
+// UpdateProfileRequest represents the request to update the user's own profile
+type UpdateProfileRequest struct {
+	FirstName   *string `json:"first_name,omitempty" binding:"omitempty,min=1,max=100"`
+	LastName    *string `json:"last_name,omitempty" binding:"omitempty,min=1,max=100"`
+	PhoneNumber *string `json:"phone_number,omitempty"` // Changing it requires verifying the new number again
+}
+
 // LoginRequest represents the login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -60,37 +98,82 @@ type LoginRequest struct {
 
 // UserResponse represents the user response (without sensitive data)
 type UserResponse struct {
-	ID              uuid.UUID  `json:"id"`
-	Email           string     `json:"email"`
-	FirstName       string     `json:"first_name"`
-	LastName        string     `json:"last_name"`
-	PhoneNumber     string     `json:"phone_number"`
-	UserType        UserType   `json:"user_type"`
-	ProfileImageURL *string    `json:"profile_image_url,omitempty"`
-	IsVerified      bool       `json:"is_verified"`
-	IsApproved      bool       `json:"is_approved"`
-	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
-	IsActive        bool       `json:"is_active"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	PhoneNumber      string     `json:"phone_number"`
+	UserType         UserType   `json:"user_type"`
+	ProfileImageURL  *string    `json:"profile_image_url,omitempty"`
+	IsVerified       bool       `json:"is_verified"`
+	IsPhoneVerified  bool       `json:"is_phone_verified"`
+	IsApproved       bool       `json:"is_approved"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	RejectedAt       *time.Time `json:"rejected_at,omitempty"`
+	RejectionReason  *string    `json:"rejection_reason,omitempty"`
+	IsSuspended      bool       `json:"is_suspended"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason *string    `json:"suspension_reason,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason *string    `json:"revocation_reason,omitempty"`
+	IsActive         bool       `json:"is_active"`
+	AgencyID         *uuid.UUID `json:"agency_id,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// AdminUserResponse adds the admins behind an agent's lifecycle changes to
+// UserResponse, for the admin endpoints
+type AdminUserResponse struct {
+	UserResponse
+	ApprovedBy   *uuid.UUID `json:"approved_by,omitempty"`
+	RejectedBy   *uuid.UUID `json:"rejected_by,omitempty"`
+	SuspendedBy  *uuid.UUID `json:"suspended_by,omitempty"`
+	RevokedBy    *uuid.UUID `json:"revoked_by,omitempty"`
+	ReinstatedAt *time.Time `json:"reinstated_at,omitempty"`
+	ReinstatedBy *uuid.UUID `json:"reinstated_by,omitempty"`
+}
+
+// ToAdminResponse converts User to AdminUserResponse
+func (u *User) ToAdminResponse() *AdminUserResponse {
+	return &AdminUserResponse{
+		UserResponse: *u.ToResponse(),
+		ApprovedBy:   u.ApprovedBy,
+		RejectedBy:   u.RejectedBy,
+		SuspendedBy:  u.SuspendedBy,
+		RevokedBy:    u.RevokedBy,
+		ReinstatedAt: u.ReinstatedAt,
+		ReinstatedBy: u.ReinstatedBy,
+	}
 }
 
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:              u.ID,
-		Email:           u.Email,
-		FirstName:       u.FirstName,
-		LastName:        u.LastName,
-		PhoneNumber:     u.PhoneNumber,
-		UserType:        u.UserType,
-		ProfileImageURL: u.ProfileImageURL,
-		IsVerified:      u.IsVerified,
-		IsApproved:      u.IsApproved,
-		ApprovedAt:      u.ApprovedAt,
-		IsActive:        u.IsActive,
-		CreatedAt:       u.CreatedAt,
-		UpdatedAt:       u.UpdatedAt,
+		ID:               u.ID,
+		Email:            u.Email,
+		FirstName:        u.FirstName,
+		LastName:         u.LastName,
+		PhoneNumber:      u.PhoneNumber,
+		UserType:         u.UserType,
+		ProfileImageURL:  u.ProfileImageURL,
+		IsVerified:       u.IsVerified,
+		IsPhoneVerified:  u.IsPhoneVerified,
+		IsApproved:       u.IsApproved,
+		ApprovedAt:       u.ApprovedAt,
+		RejectedAt:       u.RejectedAt,
+		RejectionReason:  u.RejectionReason,
+		IsSuspended:      u.IsSuspended,
+		SuspendedAt:      u.SuspendedAt,
+		SuspensionReason: u.SuspensionReason,
+		RevokedAt:        u.RevokedAt,
+		RevocationReason: u.RevocationReason,
+		IsActive:         u.IsActive,
+		AgencyID:         u.AgencyID,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
 	}
 }
 
@@ -115,18 +198,14 @@ func (u *User) GetIsVerified() bool {
 	return u.IsVerified
 }
 
-// CanManageProperties checks if user can manage properties
+// CanManageProperties checks if the user's account state allows managing properties.
+// Which properties they may manage is decided by their permissions.
 func (u *User) CanManageProperties() bool {
-	// Admins can always manage properties
-	if u.UserType == UserTypeAdmin {
-		return true
-	}
-	// Agents must be approved and verified
+	// Agents must be approved, verified and not suspended
 	if u.UserType == UserTypeAgent {
-		return u.IsVerified && u.IsApproved
+		return u.IsVerified && u.IsApproved && !u.IsSuspended
 	}
-	// Tenants cannot manage properties
-	return false
+	return true
 }
 
 // ApproveAgent approves an agent (called by admin)
@@ -138,6 +217,93 @@ func (u *User) ApproveAgent(adminID uuid.UUID) error {
 	u.IsApproved = true
 	u.ApprovedAt = &now
 	u.ApprovedBy = &adminID
+	// Approving settles any earlier rejection or revocation
+	u.RejectedAt = nil
+	u.RejectedBy = nil
+	u.RejectionReason = nil
+	u.RevokedAt = nil
+	u.RevokedBy = nil
+	u.RevocationReason = nil
+	return nil
+}
+
+// RejectAgent rejects a pending agent application (called by admin)
+func (u *User) RejectAgent(adminID uuid.UUID, reason string) error {
+	if u.UserType != UserTypeAgent {
+		return fmt.Errorf("only agents can be rejected")
+	}
+	if u.IsApproved || u.RejectedAt != nil {
+		return fmt.Errorf("%w: only pending applications can be rejected", ErrInvalidAgentTransition)
+	}
+	now := time.Now()
+	u.RejectedAt = &now
+	u.RejectedBy = &adminID
+	u.RejectionReason = &reason
+	return nil
+}
+
+// SuspendAgent temporarily blocks an approved agent (called by admin)
+func (u *User) SuspendAgent(adminID uuid.UUID, reason string) error {
+	if u.UserType != UserTypeAgent {
+		return fmt.Errorf("only agents can be suspended")
+	}
+	if !u.IsApproved || u.IsSuspended {
+		return fmt.Errorf("%w: only approved agents who are not suspended can be suspended", ErrInvalidAgentTransition)
+	}
+	now := time.Now()
+	u.IsSuspended = true
+	u.SuspendedAt = &now
+	u.SuspendedBy = &adminID
+	u.SuspensionReason = &reason
+	return nil
+}
+
+// RevokeAgentApproval withdraws an agent's approval (called by admin). The agent
+// needs to be reinstated or approved again before managing properties.
+func (u *User) RevokeAgentApproval(adminID uuid.UUID, reason string) error {
+	if u.UserType != UserTypeAgent {
+		return fmt.Errorf("only agents can have their approval revoked")
+	}
+	if !u.IsApproved {
+		return fmt.Errorf("%w: the agent is not approved", ErrInvalidAgentTransition)
+	}
+	now := time.Now()
+	u.IsApproved = false
+	u.RevokedAt = &now
+	u.RevokedBy = &adminID
+	u.RevocationReason = &reason
+	return nil
+}
+
+// IsRevoked checks if the agent's approval was revoked and not restored since
+func (u *User) IsRevoked() bool {
+	return !u.IsApproved && u.RevokedAt != nil
+}
+
+// ReinstateAgent lifts a suspension and restores a revoked approval (called by admin)
+func (u *User) ReinstateAgent(adminID uuid.UUID) error {
+	if u.UserType != UserTypeAgent {
+		return fmt.Errorf("only agents can be reinstated")
+	}
+	revoked := u.IsRevoked()
+	if !u.IsSuspended && !revoked {
+		return fmt.Errorf("%w: the agent is neither suspended nor revoked", ErrInvalidAgentTransition)
+	}
+	now := time.Now()
+	if u.IsSuspended {
+		u.IsSuspended = false
+		u.SuspendedAt = nil
+		u.SuspendedBy = nil
+		u.SuspensionReason = nil
+	}
+	if revoked {
+		u.IsApproved = true
+		u.RevokedAt = nil
+		u.RevokedBy = nil
+		u.RevocationReason = nil
+	}
+	u.ReinstatedAt = &now
+	u.ReinstatedBy = &adminID
 	return nil
 }
 
@@ -156,7 +322,8 @@ func (User) TableName() string {
 
 // UserRepository handles database operations for users
 type UserRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	invalidate func(id uuid.UUID)
 }
 
 // NewUserRepository creates a new user repository
@@ -164,6 +331,19 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// SetInvalidator registers a function called with a user's ID whenever the
+// repository changes that user, e.g. to drop them from a cache
+func (r *UserRepository) SetInvalidator(invalidate func(id uuid.UUID)) {
+	r.invalidate = invalidate
+}
+
+// changed notifies the invalidator that a user was modified
+func (r *UserRepository) changed(id uuid.UUID) {
+	if r.invalidate != nil {
+		r.invalidate(id)
+	}
+}
+
 // Create creates a new user
 func (r *UserRepository) Create(user *User) error {
 	return r.db.Create(user).Error
@@ -191,11 +371,52 @@ func (r *UserRepository) GetByEmail(email string) (*User, error) {
 
 // Update updates a user
 func (r *UserRepository) Update(user *User) error {
+	defer r.changed(user.ID)
 	return r.db.Save(user).Error
 }
 
+// RevokeAllTokens invalidates every access token and refresh session issued to a user
+func (r *UserRepository) RevokeAllTokens(id uuid.UUID) error {
+	defer r.changed(id)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return revokeAllTokens(tx, id)
+	})
+}
+
+// revokeAllTokens bumps the user's token version and revokes their refresh sessions
+func revokeAllTokens(tx *gorm.DB, id uuid.UUID) error {
+	if err := tx.Exec("UPDATE users SET token_version = token_version + 1 WHERE id = ?", id).Error; err != nil {
+		return err
+	}
+	return tx.Model(&UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// GetRoles returns the user's effective roles: the base role of their user type
+// followed by any assigned roles
+func (r *UserRepository) GetRoles(user *User) ([]Role, error) {
+	var assigned []Role
+	err := r.db.Model(&UserRole{}).Where("user_id = ?", user.ID).Order("created_at").Pluck("role", &assigned).Error
+	if err != nil {
+		return nil, err
+	}
+	return append([]Role{user.UserType.BaseRole()}, assigned...), nil
+}
+
+// ConsumeTOTPStep records a TOTP time step as used. It returns false if that step
+// or a later one was already used, so each code only works once.
+func (r *UserRepository) ConsumeTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, id, step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // Delete soft deletes a user
 func (r *UserRepository) Delete(id uuid.UUID) error {
+	defer r.changed(id)
 	return r.db.Delete(&User{}, id).Error
 }
 
@@ -206,6 +427,17 @@ func (r *UserRepository) EmailExists(email string) (bool, error) {
 	return count > 0, err
 }
 
+// MarkPhoneVerified records that the user proved ownership of a phone number,
+// which becomes their phone number
+func (r *UserRepository) MarkPhoneVerified(id uuid.UUID, phone string) error {
+	defer r.changed(id)
+	return r.db.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"phone_number":      phone,
+		"is_phone_verified": true,
+		"phone_verified_at": time.Now(),
+	}).Error
+}
+
 // PhoneExists checks if a phone number already exists
 func (r *UserRepository) PhoneExists(phone string) (bool, error) {
 	var count int64
@@ -213,10 +445,17 @@ func (r *UserRepository) PhoneExists(phone string) (bool, error) {
 	return count > 0, err
 }
 
+// AdminExists checks if there is at least one active admin
+func (r *UserRepository) AdminExists() (bool, error) {
+	var count int64
+	err := r.db.Model(&User{}).Where("user_type = ? AND is_active = ?", UserTypeAdmin, true).Count(&count).Error
+	return count > 0, err
+}
+
 // GetPendingAgents returns all agents waiting for approval
 func (r *UserRepository) GetPendingAgents() ([]User, error) {
 	var agents []User
-	err := r.db.Where("user_type = ? AND is_verified = ? AND is_approved = ? AND is_active = ?", 
+	err := r.db.Where("user_type = ? AND is_verified = ? AND is_approved = ? AND is_active = ? AND rejected_at IS NULL AND revoked_at IS NULL",
 		UserTypeAgent, true, false, true).Find(&agents).Error
 	return agents, err
 }
@@ -228,19 +467,211 @@ func (r *UserRepository) GetAllAgents() ([]User, error) {
 	return agents, err
 }
 
-// ApproveAgent approves an agent by admin
-func (r *UserRepository) ApproveAgent(agentID uuid.UUID, adminID uuid.UUID) error {
+// GetApprovedAgent retrieves an active, approved agent who is not suspended, with their agency
+func (r *UserRepository) GetApprovedAgent(id uuid.UUID) (*User, error) {
 	var agent User
-	err := r.db.Where("id = ? AND user_type = ? AND is_active = ?", agentID, UserTypeAgent, true).First(&agent).Error
+	err := r.db.Preload("Agency").
+		Where("id = ? AND user_type = ? AND is_approved = ? AND is_active = ? AND is_suspended = ?",
+			id, UserTypeAgent, true, true, false).
+		First(&agent).Error
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// UserSortColumns maps the sort options of the admin user directory to columns
+var UserSortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "first_name, last_name",
+	"email":      "email",
+	"user_type":  "user_type",
+}
+
+// UserSearchFilters represents the filters of the admin user directory
+type UserSearchFilters struct {
+	Query          string     `json:"q,omitempty"` // Matches name, email or phone number
+	UserType       *UserType  `json:"user_type,omitempty"`
+	IsVerified     *bool      `json:"is_verified,omitempty"`
+	IsApproved     *bool      `json:"is_approved,omitempty"`
+	IsActive       *bool      `json:"is_active,omitempty"`
+	RegisteredFrom *time.Time `json:"registered_from,omitempty"`
+	RegisteredTo   *time.Time `json:"registered_to,omitempty"`
+	SortBy         string     `json:"sort_by"` // A key of UserSortColumns
+	SortDesc       bool       `json:"sort_desc"`
+	Limit          int        `json:"limit"`
+	Offset         int        `json:"offset"`
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Search returns a page of users matching the filters, inactive users included,
+// and the total number of matches
+func (r *UserRepository) Search(filters *UserSearchFilters) ([]User, int64, error) {
+	query := r.db.Model(&User{})
+
+	if filters.Query != "" {
+		// Match the query literally, so % and _ in it are not wildcards
+		escaped := likeEscaper.Replace(filters.Query)
+		like := "%" + strings.ToLower(escaped) + "%"
+		query = query.Where(
+			`LOWER(first_name || ' ' || last_name) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\' OR phone_number LIKE ? ESCAPE '\'`,
+			like, like, "%"+escaped+"%",
+		)
+	}
+	if filters.UserType != nil {
+		query = query.Where("user_type = ?", *filters.UserType)
+	}
+	if filters.IsVerified != nil {
+		query = query.Where("is_verified = ?", *filters.IsVerified)
+	}
+	if filters.IsApproved != nil {
+		query = query.Where("is_approved = ?", *filters.IsApproved)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+	if filters.RegisteredFrom != nil {
+		query = query.Where("created_at >= ?", *filters.RegisteredFrom)
+	}
+	if filters.RegisteredTo != nil {
+		query = query.Where("created_at < ?", *filters.RegisteredTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := UserSortColumns[filters.SortBy]
+	if !ok {
+		column = UserSortColumns["created_at"]
 	}
-	
-	err = agent.ApproveAgent(adminID)
+	direction := " ASC"
+	if filters.SortDesc {
+		direction = " DESC"
+	}
+	// Every sort column gets the direction, and id keeps pages stable
+	order := strings.ReplaceAll(column, ",", direction+",") + direction + ", id"
+
+	var users []User
+	err := query.Order(order).Limit(filters.Limit).Offset(filters.Offset).Find(&users).Error
+	return users, total, err
+}
+
+// GetByIDIncludingInactive retrieves a user by ID whether or not they are active
+func (r *UserRepository) GetByIDIncludingInactive(id uuid.UUID) (*User, error) {
+	var user User
+	err := r.db.Where("id = ?", id).First(&user).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	
-	return r.db.Save(&agent).Error
+	return &user, nil
+}
+
+// SetActive deactivates or reactivates an account. Deactivating also revokes
+// every token of the user.
+func (r *UserRepository) SetActive(id uuid.UUID, active bool) error {
+	defer r.changed(id)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&User{}).Where("id = ?", id).Update("is_active", active)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if active {
+			return nil
+		}
+		return revokeAllTokens(tx, id)
+	})
+}
+
+// ApproveAgent approves an agent by admin. The agent's required KYC documents
+// must all be verified first.
+func (r *UserRepository) ApproveAgent(agentID uuid.UUID, adminID uuid.UUID) error {
+	_, err := r.UpdateAgent(agentID, func(agent *User) error {
+		if err := checkKYCComplete(r.db, agent); err != nil {
+			return err
+		}
+		return agent.ApproveAgent(adminID)
+	})
+	return err
+}
+
+// CheckKYCComplete returns an error wrapping ErrKYCIncomplete if the agent's
+// required documents are not all verified, see ApproveAgent
+func (r *UserRepository) CheckKYCComplete(agent *User) error {
+	return checkKYCComplete(r.db, agent)
 }
 
+// JoinAgency makes an agent a member of an agency. The agent's listings that do
+// not belong to an agency yet join it too.
+func (r *UserRepository) JoinAgency(agentID, agencyID uuid.UUID) error {
+	defer r.changed(agentID)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&User{}).
+			Where("id = ? AND user_type = ? AND is_active = ?", agentID, UserTypeAgent, true).
+			Update("agency_id", agencyID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&Property{}).
+			Where("agent_id = ? AND agency_id IS NULL", agentID).
+			Update("agency_id", agencyID).Error
+	})
+}
+
+// LeaveAgency removes a user from an agency. Their listings stay with the
+// agency so a manager can reassign them.
+func (r *UserRepository) LeaveAgency(userID, agencyID uuid.UUID) error {
+	defer r.changed(userID)
+	result := r.db.Model(&User{}).
+		Where("id = ? AND agency_id = ?", userID, agencyID).
+		Update("agency_id", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateAgent loads an active agent, applies a lifecycle action to it and saves it.
+// The agent's row stays locked until the save, so concurrent actions run one after another.
+// An agent the action suspends or whose approval it revokes loses every token.
+func (r *UserRepository) UpdateAgent(agentID uuid.UUID, action func(agent *User) error) (*User, error) {
+	defer r.changed(agentID)
+	var agent User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_type = ? AND is_active = ?", agentID, UserTypeAgent, true).
+			First(&agent).Error
+		if err != nil {
+			return err
+		}
+
+		wasApproved := agent.IsApproved && !agent.IsSuspended
+		if err := action(&agent); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&agent).Error; err != nil {
+			return err
+		}
+		if wasApproved && (!agent.IsApproved || agent.IsSuspended) {
+			return revokeAllTokens(tx, agent.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
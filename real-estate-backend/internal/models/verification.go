@@ -1,6 +1,9 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +30,39 @@ const (
 	VerificationStatusRejected  VerificationStatus = "rejected"
 )
 
+// KYCDocumentType names a kind of identity or licensing document
+type KYCDocumentType string
+
+const (
+	KYCDocumentNationalID         KYCDocumentType = "national_id"
+	KYCDocumentKRAPin             KYCDocumentType = "kra_pin"              // KRA PIN certificate
+	KYCDocumentEstateAgentLicence KYCDocumentType = "estate_agent_licence" // Issued by the Estate Agents Registration Board
+)
+
+// RequiredKYCDocuments lists the documents each user type must have verified
+// before an admin can approve them
+var RequiredKYCDocuments = map[UserType][]KYCDocumentType{
+	UserTypeAgent: {KYCDocumentNationalID, KYCDocumentKRAPin, KYCDocumentEstateAgentLicence},
+}
+
+// ErrKYCIncomplete is returned when approving a user whose required documents are not all verified
+var ErrKYCIncomplete = errors.New("required documents have not been verified")
+
+// ErrDocumentNotPending is returned when reviewing a document that is no longer pending
+var ErrDocumentNotPending = errors.New("document is not pending review")
+
+// IsValid checks if the document type is known
+func (t KYCDocumentType) IsValid() bool {
+	for _, documents := range RequiredKYCDocuments {
+		for _, document := range documents {
+			if t == document {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // UserVerification represents a verification record for a user
 type UserVerification struct {
 	ID           uuid.UUID          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
@@ -35,11 +71,15 @@ type UserVerification struct {
 	Status       VerificationStatus `json:"status" gorm:"not null;type:varchar(20);default:'pending'"`
 	Code         *string            `json:"-" gorm:"type:varchar(10)"` // OTP/verification code
 	Token        *string            `json:"-" gorm:"type:varchar(255)"` // Email verification token
+	Target       *string            `json:"target,omitempty" gorm:"type:varchar(20)"` // Phone number the OTP was sent to
+	Attempts     int                `json:"-" gorm:"not null;default:0"` // Wrong codes entered
 	ExpiresAt    *time.Time         `json:"expires_at"`
 	VerifiedAt   *time.Time         `json:"verified_at"`
 	VerifiedBy   *uuid.UUID         `json:"verified_by" gorm:"type:uuid"` // Admin who verified
 	DocumentURL  *string            `json:"document_url"`
 	DocumentType *string            `json:"document_type"`
+	DocumentPublicID *string        `json:"-" gorm:"type:varchar(255)"` // Cloudinary public ID of a privately stored document
+	DocumentFormat   *string        `json:"-" gorm:"type:varchar(10)"`
 	Notes        *string            `json:"notes" gorm:"type:text"`
 	CreatedAt    time.Time          `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt    time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
@@ -72,16 +112,24 @@ type DocumentVerificationRequest struct {
 
 // AdminVerificationRequest represents an admin action on verification
 type AdminVerificationRequest struct {
-	Status VerificationStatus `json:"status" binding:"required"`
+	Status VerificationStatus `json:"status" binding:"required,oneof=verified rejected"`
 	Notes  string            `json:"notes,omitempty"`
 }
 
+// KYCDocumentResponse represents a document in the admin review queue
+type KYCDocumentResponse struct {
+	VerificationResponse
+	User        *UserResponse `json:"user,omitempty"`
+	DocumentURL string        `json:"document_url"` // Signed URL that expires after a few minutes
+}
+
 // VerificationResponse represents a verification response
 type VerificationResponse struct {
 	ID           uuid.UUID          `json:"id"`
 	UserID       uuid.UUID          `json:"user_id"`
 	Type         VerificationType   `json:"type"`
 	Status       VerificationStatus `json:"status"`
+	Target       *string            `json:"target,omitempty"`
 	ExpiresAt    *time.Time         `json:"expires_at"`
 	VerifiedAt   *time.Time         `json:"verified_at"`
 	DocumentType *string            `json:"document_type,omitempty"`
@@ -97,6 +145,7 @@ func (v *UserVerification) ToResponse() *VerificationResponse {
 		UserID:       v.UserID,
 		Type:         v.Type,
 		Status:       v.Status,
+		Target:       v.Target,
 		ExpiresAt:    v.ExpiresAt,
 		VerifiedAt:   v.VerifiedAt,
 		DocumentType: v.DocumentType,
@@ -190,6 +239,21 @@ func (r *UserVerificationRepository) Update(verification *UserVerification) erro
 	return r.db.Save(verification).Error
 }
 
+// SaveReview saves a reviewer's decision on a document if it is still pending,
+// returning ErrDocumentNotPending otherwise
+func (r *UserVerificationRepository) SaveReview(document *UserVerification) error {
+	result := r.db.Model(document).Where("status = ?", VerificationStatusPending).
+		Select("status", "verified_at", "verified_by", "notes", "updated_at").
+		Updates(document)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDocumentNotPending
+	}
+	return nil
+}
+
 // GetPendingVerifications retrieves all pending verifications for admin review
 func (r *UserVerificationRepository) GetPendingVerifications(verificationType *VerificationType, limit, offset int) ([]*UserVerification, error) {
 	var verifications []*UserVerification
@@ -216,3 +280,102 @@ func (r *UserVerificationRepository) ExpirePendingVerifications() error {
 		Where("status = ? AND expires_at < ?", VerificationStatusPending, time.Now()).
 		Update("status", VerificationStatusExpired).Error
 }
+
+// GetDocuments retrieves a user's KYC documents, newest first
+func (r *UserVerificationRepository) GetDocuments(userID uuid.UUID) ([]*UserVerification, error) {
+	var verifications []*UserVerification
+	err := r.db.Where("user_id = ? AND type = ?", userID, VerificationTypeDocument).
+		Order("created_at DESC").Find(&verifications).Error
+	return verifications, err
+}
+
+// ExpirePendingDocuments expires a user's pending uploads of a document type,
+// so only the newest upload is reviewed
+func (r *UserVerificationRepository) ExpirePendingDocuments(userID uuid.UUID, documentType KYCDocumentType) error {
+	return r.db.Model(&UserVerification{}).
+		Where("user_id = ? AND type = ? AND document_type = ? AND status = ?",
+			userID, VerificationTypeDocument, documentType, VerificationStatusPending).
+		Update("status", VerificationStatusExpired).Error
+}
+
+// CountCreatedSince counts the verifications of a type created for a user since a time
+func (r *UserVerificationRepository) CountCreatedSince(userID uuid.UUID, verificationType VerificationType, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&UserVerification{}).
+		Where("user_id = ? AND type = ? AND created_at > ?", userID, verificationType, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ExpirePendingForUser expires a user's pending verifications of a type, so only
+// the newest code can be used
+func (r *UserVerificationRepository) ExpirePendingForUser(userID uuid.UUID, verificationType VerificationType) error {
+	return r.db.Model(&UserVerification{}).
+		Where("user_id = ? AND type = ? AND status = ?", userID, verificationType, VerificationStatusPending).
+		Update("status", VerificationStatusExpired).Error
+}
+
+// UseAttempt counts a code check against a pending verification and returns the
+// new attempt count. It reports false, counting nothing, once maxAttempts checks
+// were made, so concurrent requests cannot try more codes than allowed.
+func (r *UserVerificationRepository) UseAttempt(id uuid.UUID, maxAttempts int) (int, bool, error) {
+	var attempts int
+	result := r.db.Raw("UPDATE user_verifications SET attempts = attempts + 1, updated_at = ? WHERE id = ? AND status = ? AND attempts < ? RETURNING attempts",
+		time.Now(), id, VerificationStatusPending, maxAttempts).Scan(&attempts)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return attempts, result.RowsAffected > 0, nil
+}
+
+// GetMissingDocuments returns the required documents a user does not have verified yet
+func (r *UserVerificationRepository) GetMissingDocuments(user *User) ([]KYCDocumentType, error) {
+	return missingKYCDocuments(r.db, user)
+}
+
+// missingKYCDocuments returns the required documents a user does not have verified
+func missingKYCDocuments(db *gorm.DB, user *User) ([]KYCDocumentType, error) {
+	required := RequiredKYCDocuments[user.UserType]
+	if len(required) == 0 {
+		return nil, nil
+	}
+
+	var verified []string
+	err := db.Model(&UserVerification{}).
+		Where("user_id = ? AND type = ? AND status = ?", user.ID, VerificationTypeDocument, VerificationStatusVerified).
+		Distinct().Pluck("document_type", &verified).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []KYCDocumentType
+	for _, document := range required {
+		found := false
+		for _, v := range verified {
+			if v == string(document) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, document)
+		}
+	}
+	return missing, nil
+}
+
+// checkKYCComplete returns an error wrapping ErrKYCIncomplete if the user is missing required documents
+func checkKYCComplete(db *gorm.DB, user *User) error {
+	missing, err := missingKYCDocuments(db, user)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, document := range missing {
+			names[i] = string(document)
+		}
+		return fmt.Errorf("%w: %s", ErrKYCIncomplete, strings.Join(names, ", "))
+	}
+	return nil
+}
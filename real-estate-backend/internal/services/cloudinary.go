@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"mime/multipart"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"real-estate-backend/internal/config"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/google/uuid"
 )
@@ -55,16 +58,36 @@ func (s *CloudinaryService) UploadImage(ctx context.Context, file multipart.File
 	// Generate unique public ID
 	publicID := s.generatePublicID(propertyID, header.Filename)
 
+	return s.uploadPublicImage(ctx, file, publicID, "q_auto,f_auto", []string{"property", "real-estate", "kenya"})
+}
+
+// UploadAgencyLogo uploads an agency's logo to Cloudinary
+func (s *CloudinaryService) UploadAgencyLogo(ctx context.Context, file multipart.File, agencyID uuid.UUID) (*UploadResponse, error) {
+	publicID := fmt.Sprintf("agency_%s_logo_%d", agencyID.String(), time.Now().Unix())
+
+	return s.uploadPublicImage(ctx, file, publicID, "q_auto,f_auto", []string{"agency", "logo"})
+}
+
+// UploadProfilePhoto uploads a user's profile photo, cropped to a 400x400 square
+// centred on the face
+func (s *CloudinaryService) UploadProfilePhoto(ctx context.Context, file multipart.File, userID uuid.UUID) (*UploadResponse, error) {
+	publicID := fmt.Sprintf("user_%s_profile_%d", userID.String(), time.Now().Unix())
+
+	return s.uploadPublicImage(ctx, file, publicID, "c_thumb,g_face,w_400,h_400/q_auto,f_auto", []string{"profile"})
+}
+
+// uploadPublicImage uploads a publicly delivered image, applying the transformation on upload
+func (s *CloudinaryService) uploadPublicImage(ctx context.Context, file multipart.File, publicID, transformation string, tags []string) (*UploadResponse, error) {
 	// Upload parameters
 	invalidate := true
 	uploadParams := uploader.UploadParams{
 		PublicID:        publicID,
 		Folder:          s.config.Folder,
 		ResourceType:    "image",
-		Transformation:  "q_auto,f_auto", // Auto quality and format optimization
+		Transformation:  transformation, // e.g. "q_auto,f_auto" for auto quality and format optimization
 		AllowedFormats:  []string{"jpg", "jpeg", "png", "webp"},
 		Invalidate:      &invalidate, // Invalidate CDN cache
-		Tags:            []string{"property", "real-estate", "kenya"},
+		Tags:            tags,
 	}
 
 	// Upload the file
@@ -99,6 +122,57 @@ func (s *CloudinaryService) DeleteImage(ctx context.Context, publicID string) er
 	return nil
 }
 
+// UploadPrivateDocument uploads a KYC document with the private delivery type, so
+// it can only be fetched through a signed, expiring URL from PrivateDocumentURL
+func (s *CloudinaryService) UploadPrivateDocument(ctx context.Context, file multipart.File, header *multipart.FileHeader, userID uuid.UUID, documentType string) (*UploadResponse, error) {
+	name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	publicID := fmt.Sprintf("kyc_%s_%s_%s_%d", userID.String(), documentType,
+		strings.ToLower(strings.ReplaceAll(name, " ", "_")), time.Now().Unix())
+
+	uploadParams := uploader.UploadParams{
+		PublicID:       publicID,
+		Folder:         s.config.Folder + "/kyc",
+		ResourceType:   "image", // Cloudinary stores PDFs as images too
+		Type:           api.Private,
+		AllowedFormats: []string{"jpg", "jpeg", "png", "webp", "pdf"},
+		Tags:           []string{"kyc", documentType},
+	}
+
+	result, err := s.client.Upload.Upload(ctx, file, uploadParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload document to cloudinary: %w", err)
+	}
+
+	return &UploadResponse{
+		PublicID:     result.PublicID,
+		Format:       result.Format,
+		ResourceType: result.ResourceType,
+		Bytes:        result.Bytes,
+	}, nil
+}
+
+// PrivateDocumentURL returns a signed URL for a private document that stops working after expiresIn.
+// The URL is built here rather than with the SDK's PrivateDownloadURL, which sends
+// expires_at as a date string instead of the Unix timestamp the API expects.
+func (s *CloudinaryService) PrivateDocumentURL(publicID, format string, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	params := url.Values{}
+	params.Set("public_id", publicID)
+	params.Set("format", format)
+	params.Set("type", api.Private)
+	params.Set("expires_at", strconv.FormatInt(now.Add(expiresIn).Unix(), 10))
+	params.Set("timestamp", strconv.FormatInt(now.Unix(), 10))
+
+	signature, err := api.SignParameters(params, s.config.APISecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign document URL: %w", err)
+	}
+	params.Set("signature", signature)
+	params.Set("api_key", s.config.APIKey)
+
+	return fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/image/download?%s", s.config.CloudName, params.Encode()), nil
+}
+
 // GenerateTransformationURL generates a transformed image URL
 func (s *CloudinaryService) GenerateTransformationURL(publicID string, transformation string) string {
 	img, _ := s.client.Image(publicID)
@@ -130,6 +204,19 @@ func (s *CloudinaryService) generatePublicID(propertyID uuid.UUID, filename stri
 
 // ValidateImageFile validates if the uploaded file is a valid image
 func (s *CloudinaryService) ValidateImageFile(header *multipart.FileHeader, allowedTypes []string) error {
+	return validateUpload(header, allowedTypes, []string{".jpg", ".jpeg", ".png", ".webp"})
+}
+
+// ValidateDocumentFile validates if the uploaded file is a valid KYC document:
+// a photo or scan in one of the allowed image types, or a PDF
+func (s *CloudinaryService) ValidateDocumentFile(header *multipart.FileHeader, allowedTypes []string) error {
+	return validateUpload(header,
+		append(append([]string{}, allowedTypes...), "application/pdf"),
+		[]string{".jpg", ".jpeg", ".png", ".webp", ".pdf"})
+}
+
+// validateUpload checks an uploaded file's size, content type and extension
+func validateUpload(header *multipart.FileHeader, allowedTypes, allowedExts []string) error {
 	// Check file size
 	if header.Size > 10485760 { // 10MB
 		return fmt.Errorf("file size exceeds maximum limit of 10MB")
@@ -156,7 +243,6 @@ func (s *CloudinaryService) ValidateImageFile(header *multipart.FileHeader, allo
 
 	// Check file extension
 	ext := strings.ToLower(filepath.Ext(header.Filename))
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".webp"}
 	extAllowed := false
 	for _, allowedExt := range allowedExts {
 		if ext == allowedExt {
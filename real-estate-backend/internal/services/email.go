@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"time"
 
 	"real-estate-backend/internal/config"
 )
@@ -286,6 +287,105 @@ The %s Team
 `, data.UserName, data.CompanyName, data.ResetURL, data.CompanyName)
 }
 
+// InvitationEmailData holds data for invitation email template
+type InvitationEmailData struct {
+	InviterName     string
+	Role            string
+	AcceptURL       string
+	CompanyName     string
+	ExpirationHours int
+}
+
+// SendInvitationEmail sends an invitation to join as an admin or staff member
+func (s *EmailService) SendInvitationEmail(to, inviterName, role, invitationToken string, expirationHours int) error {
+	// Create invitation URL
+	acceptURL := fmt.Sprintf("%s/web/accept-invitation?token=%s", s.config.BaseURL, invitationToken)
+
+	// Prepare email data
+	data := InvitationEmailData{
+		InviterName:     inviterName,
+		Role:            role,
+		AcceptURL:       acceptURL,
+		CompanyName:     "Real Estate Platform",
+		ExpirationHours: expirationHours,
+	}
+
+	// Generate email content
+	subject := "You're Invited to Real Estate Platform"
+	htmlBody, err := s.generateInvitationEmailHTML(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	textBody := s.generateInvitationEmailText(data)
+
+	return s.sendEmail(to, subject, textBody, htmlBody)
+}
+
+// generateInvitationEmailHTML generates HTML email content for an invitation
+func (s *EmailService) generateInvitationEmailHTML(data InvitationEmailData) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>You're Invited</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #2c3e50; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; }
+        .button { display: inline-block; background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; }
+        .button:hover { background-color: #2980b9; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.CompanyName}}</h1>
+        <h2>You're Invited</h2>
+    </div>
+    <div class="content">
+        <p>Hello,</p>
+        <p>{{.InviterName}} has invited you to join {{.CompanyName}} as {{.Role}}. Click the link below to set your password and activate your account:</p>
+        <p style="text-align: center;">
+            <a href="{{.AcceptURL}}" class="button">Accept Invitation</a>
+        </p>
+        <p>This invitation expires in {{.ExpirationHours}} hours. If you were not expecting it, you can ignore this email.</p>
+        <p>Thank you,<br>The {{.CompanyName}} Team</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email. Please do not reply to this message.</p>
+    </div>
+</body>
+</html>`
+	tmpl, err := template.New("invitation").Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// generateInvitationEmailText generates plain text email content for an invitation
+func (s *EmailService) generateInvitationEmailText(data InvitationEmailData) string {
+	return fmt.Sprintf(`
+Hello,
+
+%s has invited you to join %s as %s. Open the link below to set your password and activate your account:
+%s
+
+This invitation expires in %d hours. If you were not expecting it, you can ignore this email.
+
+Thank you,
+The %s Team
+`, data.InviterName, data.CompanyName, data.Role, data.AcceptURL, data.ExpirationHours, data.CompanyName)
+}
+
 // SendWelcomeEmail sends a welcome email after successful verification
 func (s *EmailService) SendWelcomeEmail(to, userName string) error {
 	subject := "Welcome to Real Estate Platform!"
@@ -343,3 +443,385 @@ The Real Estate Platform Team
 
 	return s.sendEmail(to, subject, textBody, htmlBody)
 }
+
+// AccountLockedEmailData holds data for the account lockout email template
+type AccountLockedEmailData struct {
+	UserName    string
+	IPAddress   string
+	LockedUntil string
+	CompanyName string
+}
+
+// SendAccountLockedEmail tells the account owner that repeated failed logins locked their account
+func (s *EmailService) SendAccountLockedEmail(to, userName, ipAddress string, lockedUntil time.Time) error {
+	// Prepare email data
+	data := AccountLockedEmailData{
+		UserName:    userName,
+		IPAddress:   ipAddress,
+		LockedUntil: lockedUntil.UTC().Format("15:04 MST on 2 January 2006"),
+		CompanyName: "Real Estate Platform",
+	}
+
+	// Generate email content
+	subject := "Your Account Has Been Temporarily Locked"
+	htmlBody, err := s.generateAccountLockedEmailHTML(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	textBody := s.generateAccountLockedEmailText(data)
+
+	return s.sendEmail(to, subject, textBody, htmlBody)
+}
+
+// generateAccountLockedEmailHTML generates HTML email content for an account lockout
+func (s *EmailService) generateAccountLockedEmailHTML(data AccountLockedEmailData) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Account Locked</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #c0392b; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.CompanyName}}</h1>
+        <h2>Account Temporarily Locked</h2>
+    </div>
+    <div class="content">
+        <p>Hello {{.UserName}},</p>
+        <p>We locked your account after several failed login attempts. The last attempt came from IP address {{.IPAddress}}.</p>
+        <p>You can log in again after {{.LockedUntil}}. Resetting your password also unlocks your account.</p>
+        <p>If these attempts were not you, someone may be trying to guess your password. We recommend choosing a strong, unique password and turning on two-factor authentication.</p>
+        <p>Thank you,<br>The {{.CompanyName}} Team</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email. Please do not reply to this message.</p>
+    </div>
+</body>
+</html>`
+	tmpl, err := template.New("account_locked").Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// generateAccountLockedEmailText generates plain text email content for an account lockout
+func (s *EmailService) generateAccountLockedEmailText(data AccountLockedEmailData) string {
+	return fmt.Sprintf(`
+Hello %s,
+
+We locked your account after several failed login attempts. The last attempt came from IP address %s.
+
+You can log in again after %s. Resetting your password also unlocks your account.
+
+If these attempts were not you, someone may be trying to guess your password. We recommend choosing a strong, unique password and turning on two-factor authentication.
+
+Thank you,
+The %s Team
+`, data.UserName, data.IPAddress, data.LockedUntil, data.CompanyName)
+}
+
+// AgentStatusEmailData holds data for the agent status change email template
+type AgentStatusEmailData struct {
+	UserName    string
+	Headline    string
+	Message     string
+	Reason      string
+	CompanyName string
+}
+
+// agentStatusEmails holds the subject, headline and message for each agent status change
+var agentStatusEmails = map[string][3]string{
+	"rejected": {
+		"Your Agent Application Was Not Approved",
+		"Application Not Approved",
+		"We have reviewed your agent application and are unable to approve it at this time.",
+	},
+	"suspended": {
+		"Your Agent Account Has Been Suspended",
+		"Agent Account Suspended",
+		"Your agent account has been suspended. Your listings are hidden and you cannot manage properties until the suspension is lifted.",
+	},
+	"revoked": {
+		"Your Agent Approval Has Been Revoked",
+		"Agent Approval Revoked",
+		"Your approval as an agent has been revoked. You cannot manage properties until your account is approved again.",
+	},
+	"reinstated": {
+		"Your Agent Account Has Been Reinstated",
+		"Agent Account Reinstated",
+		"Your agent account has been reinstated. You can manage your properties again and your listings are visible.",
+	},
+}
+
+// SendAgentStatusEmail tells an agent that an admin rejected, suspended, revoked or reinstated them
+func (s *EmailService) SendAgentStatusEmail(to, userName, status, reason string) error {
+	content, ok := agentStatusEmails[status]
+	if !ok {
+		return fmt.Errorf("unknown agent status %q", status)
+	}
+
+	// Prepare email data
+	data := AgentStatusEmailData{
+		UserName:    userName,
+		Headline:    content[1],
+		Message:     content[2],
+		Reason:      reason,
+		CompanyName: "Real Estate Platform",
+	}
+
+	// Generate email content
+	subject := content[0]
+	htmlBody, err := s.generateAgentStatusEmailHTML(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	textBody := s.generateAgentStatusEmailText(data)
+
+	return s.sendEmail(to, subject, textBody, htmlBody)
+}
+
+// generateAgentStatusEmailHTML generates HTML email content for an agent status change
+func (s *EmailService) generateAgentStatusEmailHTML(data AgentStatusEmailData) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Headline}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #007bff; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; }
+        .reason { background-color: #fff; border-left: 4px solid #007bff; padding: 10px 15px; margin: 20px 0; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.CompanyName}}</h1>
+        <h2>{{.Headline}}</h2>
+    </div>
+    <div class="content">
+        <p>Hello {{.UserName}},</p>
+        <p>{{.Message}}</p>
+        {{if .Reason}}<div class="reason"><strong>Reason:</strong> {{.Reason}}</div>{{end}}
+        <p>If you have questions, please contact our support team.</p>
+        <p>Thank you,<br>The {{.CompanyName}} Team</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email. Please do not reply to this message.</p>
+    </div>
+</body>
+</html>`
+	tmpl, err := template.New("agent_status").Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// generateAgentStatusEmailText generates plain text email content for an agent status change
+func (s *EmailService) generateAgentStatusEmailText(data AgentStatusEmailData) string {
+	reason := ""
+	if data.Reason != "" {
+		reason = fmt.Sprintf("\nReason: %s\n", data.Reason)
+	}
+	return fmt.Sprintf(`
+Hello %s,
+
+%s
+%s
+If you have questions, please contact our support team.
+
+Thank you,
+The %s Team
+`, data.UserName, data.Message, reason, data.CompanyName)
+}
+
+// EmailChangeEmailData holds data for the email change templates
+type EmailChangeEmailData struct {
+	UserName        string
+	NewEmail        string
+	VerificationURL string
+	CompanyName     string
+	ExpirationHours int
+}
+
+// SendEmailChangeVerificationEmail asks the user to confirm a new email address by
+// clicking a link sent to that address
+func (s *EmailService) SendEmailChangeVerificationEmail(to, userName, verificationToken string) error {
+	// Prepare email data
+	data := EmailChangeEmailData{
+		UserName:        userName,
+		NewEmail:        to,
+		VerificationURL: fmt.Sprintf("%s/api/v1/verify-email?token=%s", s.config.BaseURL, verificationToken),
+		CompanyName:     "Real Estate Platform",
+		ExpirationHours: 24,
+	}
+
+	// Generate email content
+	subject := "Confirm Your New Email Address"
+	htmlBody, err := s.generateEmailChangeVerificationHTML(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`
+Hello %s,
+
+You asked to change the email address of your %s account to %s. Please confirm the change by opening this link:
+
+%s
+
+The link expires in %d hours. Until you confirm, your account keeps using your current email address. If you didn't ask for this change, please ignore this email.
+
+Thank you,
+The %s Team
+`, data.UserName, data.CompanyName, data.NewEmail, data.VerificationURL, data.ExpirationHours, data.CompanyName)
+
+	return s.sendEmail(to, subject, textBody, htmlBody)
+}
+
+// generateEmailChangeVerificationHTML generates HTML email content for confirming a new email address
+func (s *EmailService) generateEmailChangeVerificationHTML(data EmailChangeEmailData) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Confirm Your New Email Address</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #2c5aa0; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; }
+        .button { display: inline-block; background-color: #28a745; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.CompanyName}}</h1>
+        <h2>Confirm Your New Email Address</h2>
+    </div>
+    <div class="content">
+        <p>Hello {{.UserName}},</p>
+        <p>You asked to change the email address of your account to {{.NewEmail}}. Please confirm the change.</p>
+        <p style="text-align: center;">
+            <a href="{{.VerificationURL}}" class="button">Confirm Email Address</a>
+        </p>
+        <p>The link expires in {{.ExpirationHours}} hours. Until you confirm, your account keeps using your current email address. If you didn't ask for this change, please ignore this email.</p>
+        <p>Thank you,<br>The {{.CompanyName}} Team</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email. Please do not reply to this message.</p>
+    </div>
+</body>
+</html>`
+	tmpl, err := template.New("email_change_verification").Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SendEmailChangedEmail tells the old address that the account moved to a new email address
+func (s *EmailService) SendEmailChangedEmail(to, userName, newEmail string) error {
+	// Prepare email data
+	data := EmailChangeEmailData{
+		UserName:    userName,
+		NewEmail:    newEmail,
+		CompanyName: "Real Estate Platform",
+	}
+
+	// Generate email content
+	subject := "Your Email Address Was Changed"
+	htmlBody, err := s.generateEmailChangedHTML(data)
+	if err != nil {
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	textBody := fmt.Sprintf(`
+Hello %s,
+
+The email address of your %s account was changed to %s. You will receive account emails at the new address from now on.
+
+If you didn't make this change, please contact our support team immediately.
+
+Thank you,
+The %s Team
+`, data.UserName, data.CompanyName, data.NewEmail, data.CompanyName)
+
+	return s.sendEmail(to, subject, textBody, htmlBody)
+}
+
+// generateEmailChangedHTML generates HTML email content for an email address change notice
+func (s *EmailService) generateEmailChangedHTML(data EmailChangeEmailData) (string, error) {
+	templateString := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Email Address Changed</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #c0392b; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 5px 5px; }
+        .footer { margin-top: 30px; font-size: 12px; color: #666; text-align: center; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.CompanyName}}</h1>
+        <h2>Email Address Changed</h2>
+    </div>
+    <div class="content">
+        <p>Hello {{.UserName}},</p>
+        <p>The email address of your account was changed to {{.NewEmail}}. You will receive account emails at the new address from now on.</p>
+        <p>If you didn't make this change, please contact our support team immediately.</p>
+        <p>Thank you,<br>The {{.CompanyName}} Team</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email. Please do not reply to this message.</p>
+    </div>
+</body>
+</html>`
+	tmpl, err := template.New("email_changed").Parse(templateString)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"real-estate-backend/internal/config"
+	"real-estate-backend/internal/models"
+)
+
+// AttemptStore keeps failed-attempt counters and locks. The memory store is the
+// default; multi-instance deployments should use the database store so every
+// instance sees the same counters.
+type AttemptStore interface {
+	RecordFailure(key string, window time.Duration, now time.Time) (int, error)
+	Lock(key string, until time.Time) error
+	Get(key string) (*models.LoginAttempt, error)
+	Clear(key string) error
+	GetLocked(now time.Time) ([]models.LoginAttempt, error)
+}
+
+// NewAttemptStore creates the store selected by ATTEMPT_STORE
+func NewAttemptStore(cfg *config.SecurityConfig, repo *models.LoginAttemptRepository) (AttemptStore, error) {
+	switch cfg.AttemptStore {
+	case "", "memory":
+		return NewMemoryAttemptStore(), nil
+	case "database":
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown attempt store %q", cfg.AttemptStore)
+	}
+}
+
+// MemoryAttemptStore keeps counters in process memory
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*models.LoginAttempt
+}
+
+// NewMemoryAttemptStore creates an empty in-memory store
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]*models.LoginAttempt)}
+}
+
+// RecordFailure adds a failure for the key and returns the number of failures in the current window
+func (s *MemoryAttemptStore) RecordFailure(key string, window time.Duration, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(window, now)
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &models.LoginAttempt{Key: key}
+		s.attempts[key] = attempt
+	}
+	if attempt.LastFailureAt.Before(now.Add(-window)) {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	return attempt.Failures, nil
+}
+
+// Lock locks the key until the given time
+func (s *MemoryAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+// Get retrieves a copy of the attempts for a key, or nil if there are none
+func (s *MemoryAttemptStore) Get(key string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+// Clear forgets all failures and any lock for a key
+func (s *MemoryAttemptStore) Clear(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// GetLocked retrieves the keys that are locked at the given time
+func (s *MemoryAttemptStore) GetLocked(now time.Time) ([]models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locked := []models.LoginAttempt{}
+	for _, attempt := range s.attempts {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			locked = append(locked, *attempt)
+		}
+	}
+	return locked, nil
+}
+
+// prune drops counters that can no longer block anything. The caller holds the lock.
+func (s *MemoryAttemptStore) prune(window time.Duration, now time.Time) {
+	if len(s.attempts) < 10000 {
+		return
+	}
+	for key, attempt := range s.attempts {
+		locked := attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		if !locked && attempt.LastFailureAt.Before(now.Add(-window)) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+// LockoutPolicy describes how failures on one kind of key are throttled
+type LockoutPolicy struct {
+	FreeAttempts     int           // Failures allowed before delays start
+	BaseDelay        time.Duration // Delay after the first failure past FreeAttempts; doubles with each failure
+	MaxDelay         time.Duration
+	LockoutThreshold int // Failures that lock the key
+	LockoutDuration  time.Duration
+	Window           time.Duration // Failures older than this are forgotten
+}
+
+var (
+	// AccountLockoutPolicy applies to keys naming an account
+	AccountLockoutPolicy = LockoutPolicy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         30 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		Window:           15 * time.Minute,
+	}
+
+	// IPLockoutPolicy applies to keys naming a client IP. It is looser than the
+	// account policy because many users can share an address.
+	IPLockoutPolicy = LockoutPolicy{
+		FreeAttempts:     10,
+		BaseDelay:        time.Second,
+		MaxDelay:         30 * time.Second,
+		LockoutThreshold: 100,
+		LockoutDuration:  15 * time.Minute,
+		Window:           15 * time.Minute,
+	}
+)
+
+// AccountAttemptKey returns the throttling key for login attempts on an account
+func AccountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// PasswordResetAttemptKey returns the throttling key for password reset requests for an account
+func PasswordResetAttemptKey(email string) string {
+	return "reset:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPAttemptKey returns the throttling key for attempts from a client IP
+func IPAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// PasswordResetIPAttemptKey returns the throttling key for password reset requests
+// from a client IP, kept apart from IPAttemptKey so reset requests do not lock the IP out of logging in
+func PasswordResetIPAttemptKey(ip string) string {
+	return "reset-ip:" + ip
+}
+
+// LoginThrottle slows down and then locks out repeated failures on a key
+type LoginThrottle struct {
+	store AttemptStore
+}
+
+// NewLoginThrottle creates a login throttle backed by the given store
+func NewLoginThrottle(store AttemptStore) *LoginThrottle {
+	return &LoginThrottle{store: store}
+}
+
+// Check returns how long the caller must wait before another attempt on any of
+// the keys is allowed. Zero means the attempt may go ahead.
+func (t *LoginThrottle) Check(keys ...string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys {
+		attempt, err := t.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt == nil {
+			continue
+		}
+		if d := policyFor(key).retryAfter(attempt, now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failure on the key. It reports whether this failure
+// locked the key and, if so, until when.
+func (t *LoginThrottle) RecordFailure(key string) (bool, time.Time, error) {
+	policy := policyFor(key)
+	now := time.Now()
+
+	failures, err := t.store.RecordFailure(key, policy.Window, now)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if failures != policy.LockoutThreshold {
+		return false, time.Time{}, nil
+	}
+
+	until := now.Add(policy.LockoutDuration)
+	if err := t.store.Lock(key, until); err != nil {
+		return false, time.Time{}, err
+	}
+	return true, until, nil
+}
+
+// Reset clears the failures and any lock on the key
+func (t *LoginThrottle) Reset(key string) error {
+	return t.store.Clear(key)
+}
+
+// GetLocks retrieves every key that is currently locked
+func (t *LoginThrottle) GetLocks() ([]models.LoginAttempt, error) {
+	return t.store.GetLocked(time.Now())
+}
+
+// policyFor picks the policy for a key by its prefix
+func policyFor(key string) LockoutPolicy {
+	if strings.HasPrefix(key, "ip:") || strings.HasPrefix(key, "reset-ip:") {
+		return IPLockoutPolicy
+	}
+	return AccountLockoutPolicy
+}
+
+// retryAfter returns how long the key is blocked for at the given time
+func (p LockoutPolicy) retryAfter(attempt *models.LoginAttempt, now time.Time) time.Duration {
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		return attempt.LockedUntil.Sub(now)
+	}
+
+	if attempt.LastFailureAt.Before(now.Add(-p.Window)) || attempt.Failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay << uint(attempt.Failures-p.FreeAttempts-1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if until := attempt.LastFailureAt.Add(delay); until.After(now) {
+		return until.Sub(now)
+	}
+	return 0
+}
@@ -258,28 +258,7 @@ func (m *MPesaService) QuerySTKPush(checkoutRequestID string) (*STKQueryResponse
 
 // ValidatePhoneNumber validates and formats a Kenyan phone number for M-Pesa
 func (m *MPesaService) ValidatePhoneNumber(phoneNumber string) (string, error) {
-	// Remove any spaces, dashes, or plus signs
-	cleaned := ""
-	for _, char := range phoneNumber {
-		if char >= '0' && char <= '9' {
-			cleaned += string(char)
-		}
-	}
-
-	// Handle different formats
-	switch {
-	case len(cleaned) == 10 && cleaned[:1] == "0":
-		// 0712345678 -> 254712345678
-		return "254" + cleaned[1:], nil
-	case len(cleaned) == 9:
-		// 712345678 -> 254712345678
-		return "254" + cleaned, nil
-	case len(cleaned) == 12 && cleaned[:3] == "254":
-		// 254712345678 -> 254712345678
-		return cleaned, nil
-	default:
-		return "", fmt.Errorf("invalid phone number format: %s", phoneNumber)
-	}
+	return NormalizePhoneNumber(phoneNumber)
 }
 
 // FormatAmount formats amount to string without decimals (M-Pesa expects whole numbers)
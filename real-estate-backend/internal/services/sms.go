@@ -0,0 +1,126 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"real-estate-backend/internal/config"
+)
+
+// SMSSender delivers text messages. Implementations wrap a gateway such as
+// Africa's Talking; the log and memory senders are for development.
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+// NewSMSSender creates the sender selected by SMS_PROVIDER. The development
+// senders are refused in production, where they would leak verification codes
+// into the log instead of delivering them.
+func NewSMSSender(cfg *config.SMSConfig) (SMSSender, error) {
+	if cfg.Environment == "production" && (cfg.Provider == "log" || cfg.Provider == "memory") {
+		return nil, fmt.Errorf("SMS provider %q is for development and cannot be used in production", cfg.Provider)
+	}
+
+	switch cfg.Provider {
+	case "log":
+		return &LogSMSSender{senderID: cfg.SenderID}, nil
+	case "memory":
+		return NewMemorySMSSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.Provider)
+	}
+}
+
+// LogSMSSender writes messages to the application log instead of sending them
+type LogSMSSender struct {
+	senderID string
+}
+
+// Send logs the message
+func (s *LogSMSSender) Send(to, message string) error {
+	log.Printf("SMS from %s to %s: %s", s.senderID, to, message)
+	return nil
+}
+
+// SMSMessage is a message captured by MemorySMSSender
+type SMSMessage struct {
+	To      string
+	Message string
+}
+
+// MemorySMSSender keeps sent messages in memory so they can be inspected
+type MemorySMSSender struct {
+	mu       sync.Mutex
+	messages []SMSMessage
+}
+
+// NewMemorySMSSender creates an empty in-memory sender
+func NewMemorySMSSender() *MemorySMSSender {
+	return &MemorySMSSender{}
+}
+
+// Send records the message
+func (s *MemorySMSSender) Send(to, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, SMSMessage{To: to, Message: message})
+	return nil
+}
+
+// Messages returns the messages sent to a phone number, oldest first
+func (s *MemorySMSSender) Messages(to string) []SMSMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []SMSMessage
+	for _, message := range s.messages {
+		if message.To == to {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// NormalizePhoneNumber validates a Kenyan phone number and returns it in the
+// international 2547XXXXXXXX form used by M-Pesa and SMS gateways
+func NormalizePhoneNumber(phoneNumber string) (string, error) {
+	// Remove any spaces, dashes, or plus signs
+	cleaned := ""
+	for _, char := range phoneNumber {
+		if char >= '0' && char <= '9' {
+			cleaned += string(char)
+		}
+	}
+
+	// Handle different formats
+	switch {
+	case len(cleaned) == 10 && cleaned[:1] == "0":
+		// 0712345678 -> 254712345678
+		return "254" + cleaned[1:], nil
+	case len(cleaned) == 9:
+		// 712345678 -> 254712345678
+		return "254" + cleaned, nil
+	case len(cleaned) == 12 && cleaned[:3] == "254":
+		// 254712345678 -> 254712345678
+		return cleaned, nil
+	default:
+		return "", fmt.Errorf("invalid phone number format: %s", phoneNumber)
+	}
+}
+
+// GenerateOTPCode generates a random numeric one-time code
+func GenerateOTPCode(digits int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
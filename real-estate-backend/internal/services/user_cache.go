@@ -0,0 +1,82 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"real-estate-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UserCache keeps recently loaded users for a short time so that per-request
+// user loading does not hit the database on every request. Each instance has
+// its own cache, so changes made through another instance show up after at
+// most one TTL.
+type UserCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[uuid.UUID]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+// NewUserCache creates a user cache. A zero TTL disables caching.
+func NewUserCache(ttl time.Duration) *UserCache {
+	return &UserCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]userCacheEntry),
+	}
+}
+
+// Get returns a copy of a cached user
+func (c *UserCache) Get(id uuid.UUID) (*models.User, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, id)
+		return nil, false
+	}
+	user := entry.user
+	return &user, true
+}
+
+// Set caches a copy of a user
+func (c *UserCache) Set(user *models.User) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= 10000 {
+		for id, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.entries[user.ID] = userCacheEntry{user: *user, expiresAt: now.Add(c.ttl)}
+}
+
+// Invalidate drops a user from the cache
+func (c *UserCache) Invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+}
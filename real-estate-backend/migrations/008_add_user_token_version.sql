@@ -0,0 +1,4 @@
+-- Migration: 008_add_user_token_version.sql
+-- Per-user token version; bumping it invalidates every issued access token
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,42 @@
+-- Migration: 009_create_invitations.sql
+-- Emailed, expiring invitations for admin and staff accounts
+
+CREATE TABLE invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    user_type VARCHAR(20) NOT NULL CHECK (user_type IN ('admin', 'staff')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by UUID,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by UUID,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (accepted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_invitations_email ON invitations(email);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_invitations_updated_at BEFORE UPDATE ON invitations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Allow staff accounts
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_user_type_check;
+ALTER TABLE users ADD CONSTRAINT users_user_type_check
+    CHECK (user_type IN ('landlord', 'tenant', 'agent', 'admin', 'staff'));
+
+-- Deactivate the admin seeded by 006 if it still uses the published default password
+UPDATE users
+SET is_active = false, token_version = token_version + 1
+WHERE email = 'admin@realestate.com'
+  AND password_hash = '$2a$10$SQvttWpt6CqPukw.x00zkeV0b.8v6gELaOYQaN5IHssj/WkHzjlYq';
+
+UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP
+WHERE revoked_at IS NULL
+  AND user_id IN (SELECT id FROM users WHERE email = 'admin@realestate.com' AND is_active = false);
+
+COMMENT ON TABLE users IS 'Users table with admin, staff, agent, and tenant user types';
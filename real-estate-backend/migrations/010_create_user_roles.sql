@@ -0,0 +1,16 @@
+-- Migration: 010_create_user_roles.sql
+-- Additional roles assigned to users on top of the base role of their user type.
+-- Roles and the permissions they grant are defined in code (internal/models/permission.go).
+
+CREATE TABLE user_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    granted_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (granted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- One row per user and role
+CREATE UNIQUE INDEX idx_user_roles_user_role ON user_roles(user_id, role);
@@ -0,0 +1,30 @@
+-- Migration: 012_add_phone_verification.sql
+-- Phone number verification by SMS one-time code, stored in user_verifications.
+
+ALTER TABLE users ADD COLUMN is_phone_verified BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMP;
+
+CREATE TABLE user_verifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    code VARCHAR(10),
+    token VARCHAR(255),
+    target VARCHAR(20),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    verified_at TIMESTAMP,
+    verified_by UUID,
+    document_url TEXT,
+    document_type TEXT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (verified_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_user_verifications_user_type ON user_verifications(user_id, type, created_at);
+CREATE INDEX idx_user_verifications_deleted_at ON user_verifications(deleted_at);
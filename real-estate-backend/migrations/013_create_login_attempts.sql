@@ -0,0 +1,12 @@
+-- Migration: 013_create_login_attempts.sql
+-- Failed login and password reset attempts, used when ATTEMPT_STORE=database so that
+-- every instance of a multi-instance deployment shares the same counters.
+
+CREATE TABLE login_attempts (
+    key VARCHAR(320) PRIMARY KEY, -- e.g. 'account:jane@example.com' or 'ip:203.0.113.7'
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_locked_until ON login_attempts(locked_until);
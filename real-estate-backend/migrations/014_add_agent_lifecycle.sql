@@ -0,0 +1,22 @@
+-- Migration: 014_add_agent_lifecycle.sql
+-- Agent rejection, suspension, approval revocation and reinstatement, each
+-- recording the admin who acted and when.
+
+ALTER TABLE users ADD COLUMN rejected_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN rejected_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN rejection_reason TEXT;
+
+ALTER TABLE users ADD COLUMN is_suspended BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN suspended_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT;
+
+ALTER TABLE users ADD COLUMN revoked_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN revoked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN revocation_reason TEXT;
+
+ALTER TABLE users ADD COLUMN reinstated_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN reinstated_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Public search leaves out listings of suspended agents
+CREATE INDEX idx_users_suspended ON users(id) WHERE is_suspended = true;
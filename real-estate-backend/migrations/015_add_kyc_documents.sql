@@ -0,0 +1,9 @@
+-- Migration: 015_add_kyc_documents.sql
+-- KYC documents are stored privately in Cloudinary and reviewed by admins
+-- through user_verifications rows of type 'document'.
+
+ALTER TABLE user_verifications ADD COLUMN document_public_id VARCHAR(255);
+ALTER TABLE user_verifications ADD COLUMN document_format VARCHAR(10);
+
+CREATE INDEX idx_user_verifications_review_queue ON user_verifications(type, status, created_at)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,26 @@
+-- Migration: 016_create_agencies.sql
+-- Agencies group agents who share a portfolio of listings.
+
+CREATE TABLE agencies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    logo_url TEXT,
+    logo_public_id VARCHAR(255),
+    email VARCHAR(255) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    website TEXT,
+    address TEXT,
+    licence_number VARCHAR(100) NOT NULL UNIQUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_agencies_deleted_at ON agencies(deleted_at);
+
+ALTER TABLE users ADD COLUMN agency_id UUID REFERENCES agencies(id) ON DELETE SET NULL;
+CREATE INDEX idx_users_agency_id ON users(agency_id);
+
+ALTER TABLE properties ADD COLUMN agency_id UUID REFERENCES agencies(id) ON DELETE SET NULL;
+CREATE INDEX idx_properties_agency_id ON properties(agency_id);
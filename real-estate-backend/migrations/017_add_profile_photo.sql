@@ -0,0 +1,4 @@
+-- Migration: 017_add_profile_photo.sql
+-- Keep the Cloudinary public ID of the profile photo so a replaced photo can be deleted.
+
+ALTER TABLE users ADD COLUMN profile_image_public_id VARCHAR(255);
@@ -0,0 +1,7 @@
+-- Migration: 018_add_email_change.sql
+-- An email verification with a new_email confirms a change of address rather than
+-- the address the user registered with.
+
+ALTER TABLE email_verifications ADD COLUMN new_email VARCHAR(255);
+
+CREATE INDEX idx_email_verifications_pending_change ON email_verifications(user_id) WHERE new_email IS NOT NULL AND is_used = false;
@@ -0,0 +1,5 @@
+-- Migration: 019_add_stats_indexes.sql
+-- Indexes for the date-range queries behind the admin dashboard statistics.
+
+CREATE INDEX idx_users_created_at ON users(created_at);
+CREATE INDEX idx_properties_created_at ON properties(created_at);
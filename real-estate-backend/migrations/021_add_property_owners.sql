@@ -0,0 +1,8 @@
+-- Migration: 021_add_property_owners.sql
+-- Landlords own properties that agents list and manage on their behalf.
+-- users_user_type_check already allows 'landlord' (see 009).
+
+ALTER TABLE properties ADD COLUMN owner_id UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_properties_owner_id ON properties(owner_id);
+
+CREATE INDEX idx_leases_landlord_id ON leases(landlord_id);
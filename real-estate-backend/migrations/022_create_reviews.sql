@@ -0,0 +1,42 @@
+-- Migration: 022_create_reviews.sql
+-- Tenant reviews of properties and agents, agent replies and reports for moderation.
+
+CREATE TABLE reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('property', 'agent')),
+    target_id UUID NOT NULL,
+    tenant_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    agent_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    scores JSONB NOT NULL,
+    comment TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'flagged', 'removed')),
+    report_count INTEGER NOT NULL DEFAULT 0,
+    reply TEXT,
+    replied_at TIMESTAMP WITH TIME ZONE,
+    moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    moderation_notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- target_id names a property or an agent, so it has no foreign key
+CREATE UNIQUE INDEX idx_reviews_tenant_target ON reviews(target_type, target_id, tenant_id);
+CREATE INDEX idx_reviews_target ON reviews(target_type, target_id);
+CREATE INDEX idx_reviews_agent_id ON reviews(agent_id);
+CREATE INDEX idx_reviews_status ON reviews(status);
+
+CREATE TRIGGER update_reviews_updated_at BEFORE UPDATE ON reviews
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE review_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    review_id UUID NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_review_reports_review_reporter ON review_reports(review_id, reporter_id);
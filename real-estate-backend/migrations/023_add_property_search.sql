@@ -0,0 +1,51 @@
+-- Migration: 023_add_property_search.sql
+-- Full-text search over listings. search_vector weights the title highest, then the
+-- location and county names, then the description, and triggers keep it current.
+
+ALTER TABLE properties ADD COLUMN search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION property_search_vector(
+    p_title VARCHAR, p_description TEXT, p_location_details TEXT, p_county_id INTEGER, p_sub_county_id INTEGER
+) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', coalesce(p_title, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce(p_location_details, '')), 'B') ||
+           setweight(to_tsvector('english', coalesce((SELECT name FROM counties WHERE id = p_county_id), '')), 'B') ||
+           setweight(to_tsvector('english', coalesce((SELECT name FROM sub_counties WHERE id = p_sub_county_id), '')), 'B') ||
+           setweight(to_tsvector('english', coalesce(p_description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION update_property_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := property_search_vector(NEW.title, NEW.description, NEW.location_details, NEW.county_id, NEW.sub_county_id);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER properties_search_vector BEFORE INSERT OR UPDATE OF title, description, location_details, county_id, sub_county_id ON properties
+    FOR EACH ROW EXECUTE FUNCTION update_property_search_vector();
+
+-- Renaming a county or sub-county changes the text of its listings
+CREATE OR REPLACE FUNCTION refresh_location_search_vectors()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'counties' THEN
+        UPDATE properties SET search_vector = property_search_vector(title, description, location_details, county_id, sub_county_id)
+        WHERE county_id = NEW.id;
+    ELSE
+        UPDATE properties SET search_vector = property_search_vector(title, description, location_details, county_id, sub_county_id)
+        WHERE sub_county_id = NEW.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER counties_search_vectors AFTER UPDATE OF name ON counties
+    FOR EACH ROW EXECUTE FUNCTION refresh_location_search_vectors();
+
+CREATE TRIGGER sub_counties_search_vectors AFTER UPDATE OF name ON sub_counties
+    FOR EACH ROW EXECUTE FUNCTION refresh_location_search_vectors();
+
+UPDATE properties SET search_vector = property_search_vector(title, description, location_details, county_id, sub_county_id);
+
+CREATE INDEX idx_properties_search_vector ON properties USING GIN (search_vector);
@@ -0,0 +1,6 @@
+-- Migration: 024_add_property_geohash.sql
+-- Geohash of each property's coordinates, for grouping map markers into clusters.
+-- The application sets it on save and fills it in for existing properties at startup.
+
+ALTER TABLE properties ADD COLUMN geohash VARCHAR(12);
+CREATE INDEX idx_properties_geohash ON properties(geohash);
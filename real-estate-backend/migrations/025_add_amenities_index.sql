@@ -0,0 +1,7 @@
+-- Migration: 025_add_amenities_index.sql
+-- Amenities are stored as catalog keys set to true (see AmenityCatalog), so listings
+-- can be filtered with JSONB containment: amenities @> '{"borehole": true}'.
+-- Free-form amenities saved earlier are kept; they match no filter until the agent
+-- picks them from the catalog.
+
+CREATE INDEX idx_properties_amenities ON properties USING GIN (amenities);
@@ -0,0 +1,10 @@
+-- Migration: 026_add_property_sort_indexes.sql
+-- Listings are paged by keyset on (sort key, created_at DESC, id DESC), so each
+-- index below serves one sort, reading only the page it returns.
+
+CREATE INDEX idx_properties_newest ON properties(created_at DESC, id DESC);
+CREATE INDEX idx_properties_rent_newest ON properties(rent_amount, created_at DESC, id DESC);
+CREATE INDEX idx_properties_bedrooms_newest ON properties(bedrooms, created_at DESC, id DESC);
+
+-- An agent's own listings, newest first
+CREATE INDEX idx_properties_agent_newest ON properties(agent_id, created_at DESC, id DESC);
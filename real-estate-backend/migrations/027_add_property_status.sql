@@ -0,0 +1,23 @@
+-- Migration: 027_add_property_status.sql
+-- Listing lifecycle: draft -> pending_review -> published -> let/archived. Moderators
+-- approve listings before they go public, or send them back to draft with a reason.
+-- is_available is kept in step: true exactly while a listing is published.
+
+ALTER TABLE properties ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'pending_review', 'published', 'let', 'archived'));
+ALTER TABLE properties ADD COLUMN rejection_reason TEXT;
+ALTER TABLE properties ADD COLUMN submitted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE properties ADD COLUMN reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE properties ADD COLUMN reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE properties ADD COLUMN published_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE properties ALTER COLUMN is_available SET DEFAULT FALSE;
+
+-- Listings went live when created, so existing ones are published, or let if unavailable
+UPDATE properties SET
+    status = CASE WHEN is_available THEN 'published' ELSE 'let' END,
+    published_at = created_at;
+
+CREATE INDEX idx_properties_status ON properties(status);
+
+-- The moderation queue, longest waiting first
+CREATE INDEX idx_properties_pending_review ON properties(submitted_at, id) WHERE status = 'pending_review';
@@ -0,0 +1,31 @@
+-- Migration: 028_normalize_phone_numbers.sql
+-- Phone numbers are stored in the 2547... form that registration, invitations and
+-- profile updates now produce, so the uniqueness check matches one number written
+-- in different formats. Existing numbers are rewritten to that form.
+
+CREATE FUNCTION pg_temp.normalize_phone_number(raw TEXT) RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN digits ~ '^0[0-9]{9}$' THEN '254' || substr(digits, 2)
+        WHEN digits ~ '^[0-9]{9}$' THEN '254' || digits
+        WHEN digits ~ '^254[0-9]{9}$' THEN digits
+    END
+    FROM (SELECT regexp_replace(raw, '[^0-9]', '', 'g') AS digits) cleaned;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Numbers that would collide with another account's are left as they are
+UPDATE users u SET phone_number = pg_temp.normalize_phone_number(u.phone_number)
+WHERE pg_temp.normalize_phone_number(u.phone_number) IS NOT NULL
+  AND pg_temp.normalize_phone_number(u.phone_number) <> u.phone_number
+  AND NOT EXISTS (
+      SELECT 1 FROM users o
+      WHERE o.id <> u.id AND pg_temp.normalize_phone_number(o.phone_number) = pg_temp.normalize_phone_number(u.phone_number)
+  );
+
+-- Accounts sharing a number, or with a number in no known form, need resolving by hand
+SELECT id, email, phone_number FROM users u
+WHERE pg_temp.normalize_phone_number(u.phone_number) IS NULL
+   OR EXISTS (
+      SELECT 1 FROM users o
+      WHERE o.id <> u.id AND pg_temp.normalize_phone_number(o.phone_number) = pg_temp.normalize_phone_number(u.phone_number)
+  )
+ORDER BY phone_number;
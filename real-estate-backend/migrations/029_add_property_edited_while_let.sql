@@ -0,0 +1,6 @@
+-- Migration: 029_add_property_edited_while_let.sql
+-- Records that a let listing's key fields or photos changed, so that relisting it
+-- goes through moderation when LISTING_EDITS_REQUIRE_REVIEW is set. Cleared when
+-- the listing is published again.
+
+ALTER TABLE properties ADD COLUMN edited_while_let BOOLEAN NOT NULL DEFAULT FALSE;
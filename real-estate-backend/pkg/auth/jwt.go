@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -15,31 +18,103 @@ type Claims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Email    string    `json:"email"`
 	UserType string    `json:"user_type"`
+	// Roles are the user's effective roles when the token was issued
+	Roles []string `json:"roles"`
+	// TokenVersion must match the user's current version for the token to be accepted
+	TokenVersion int `json:"token_version"`
+	// MFA is true when the session was established with a second factor
+	MFA bool `json:"mfa,omitempty"`
+	// Purpose marks a restricted token, e.g. a two-factor challenge. Access tokens have none.
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// PurposeTwoFactorChallenge marks a token that can only be exchanged for an
+// access token together with a second factor
+const PurposeTwoFactorChallenge = "2fa_challenge"
+
+// challengeTokenTTL is how long a user has to enter their second factor after their password
+const challengeTokenTTL = 5 * time.Minute
+
+// ErrWrongTokenPurpose is returned when a token is used for something it was not issued for
+var ErrWrongTokenPurpose = errors.New("token cannot be used for this purpose")
+
+// TokenSubject describes the user an access token is issued to
+type TokenSubject struct {
+	UserID       uuid.UUID
+	Email        string
+	UserType     string
+	Roles        []string
+	TokenVersion int
+	MFA          bool
+}
+
 // JWTManager handles JWT operations
 type JWTManager struct {
-	secretKey   string
-	expiryHours int
+	keys            *KeySet
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
 // NewJWTManager creates a new JWT manager
-func NewJWTManager(cfg *config.JWTConfig) *JWTManager {
+func NewJWTManager(cfg *config.JWTConfig) (*JWTManager, error) {
+	keys, err := LoadKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &JWTManager{
-		secretKey:   cfg.Secret,
-		expiryHours: cfg.ExpiryHours,
+		keys:            keys,
+		accessTokenTTL:  time.Duration(cfg.AccessTokenMinutes) * time.Minute,
+		refreshTokenTTL: time.Duration(cfg.RefreshTokenDays) * 24 * time.Hour,
+	}, nil
+}
+
+// JWKS returns the public keys that verify tokens issued by this manager
+func (j *JWTManager) JWKS() JWKSet {
+	return j.keys.JWKS()
+}
+
+// AccessTokenTTL returns how long access tokens stay valid
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return j.accessTokenTTL
+}
+
+// RefreshTokenTTL returns how long refresh tokens stay valid
+func (j *JWTManager) RefreshTokenTTL() time.Duration {
+	return j.refreshTokenTTL
+}
+
+// GenerateToken generates a new short-lived access token for a user
+func (j *JWTManager) GenerateToken(subject TokenSubject) (string, error) {
+	claims := &Claims{
+		UserID:       subject.UserID,
+		Email:        subject.Email,
+		UserType:     subject.UserType,
+		Roles:        subject.Roles,
+		TokenVersion: subject.TokenVersion,
+		MFA:          subject.MFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "real-estate-backend",
+			Subject:   subject.UserID.String(),
+		},
 	}
+
+	return j.sign(claims)
 }
 
-// GenerateToken generates a new JWT token for a user
-func (j *JWTManager) GenerateToken(userID uuid.UUID, email, userType string) (string, error) {
+// GenerateChallengeToken generates a short-lived token proving the password step
+// of a two-factor login. It is not accepted as an access token.
+func (j *JWTManager) GenerateChallengeToken(userID uuid.UUID, tokenVersion int) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Email:    email,
-		UserType: userType,
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		Purpose:      PurposeTwoFactorChallenge,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.expiryHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(challengeTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "real-estate-backend",
@@ -47,17 +122,59 @@ func (j *JWTManager) GenerateToken(userID uuid.UUID, email, userType string) (st
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
+}
+
+// ChallengeTokenTTL returns how long two-factor challenge tokens stay valid
+func (j *JWTManager) ChallengeTokenTTL() time.Duration {
+	return challengeTokenTTL
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// sign signs claims with the active key
+func (j *JWTManager) sign(claims *Claims) (string, error) {
+	key := j.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// ValidateToken validates an access token and returns the claims
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
+
+// ValidateChallengeToken validates a two-factor challenge token and returns the claims
+func (j *JWTManager) ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeTwoFactorChallenge {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
+
+// parse verifies a token's signature and registered claims
+func (j *JWTManager) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys.Lookup(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// Only accept the algorithm the key was issued for
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(j.secretKey), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -71,18 +188,19 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken generates a new token with extended expiry
-func (j *JWTManager) RefreshToken(tokenString string) (string, error) {
-	claims, err := j.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Check if token is close to expiry (within 1 hour)
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
-		return "", errors.New("token is not close to expiry")
+// GenerateRefreshToken generates an opaque refresh token and the hash that
+// should be persisted for it. Only the hash is ever stored server-side.
+func GenerateRefreshToken() (token string, tokenHash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
 	}
-
-	return j.GenerateToken(claims.UserID, claims.Email, claims.UserType)
+	token = hex.EncodeToString(bytes)
+	return token, HashRefreshToken(token), nil
 }
 
+// HashRefreshToken returns the SHA-256 hash of a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"real-estate-backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hmacKeyID is the key ID used for the shared-secret key in HS256 mode
+const hmacKeyID = "hs256"
+
+// SigningKey is a key that can verify, and optionally sign, tokens
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{} // nil for verification-only keys
+	verifyKey interface{}
+}
+
+// CanSign reports whether the private half of the key is available
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeySet holds the active signing key and every key that is still accepted for verification
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// JWK represents a single public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet represents a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeySet builds the key set described by the JWT configuration.
+// In HS256 mode the shared secret is the only key. Otherwise every *.pem file in
+// the keys directory is loaded with its file name (minus extension) as the key ID;
+// private keys can sign, public keys only verify. Deleting a file retires its key.
+func LoadKeySet(cfg *config.JWTConfig) (*KeySet, error) {
+	if cfg.Algorithm == jwt.SigningMethodHS256.Alg() {
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("JWT secret is required for %s", cfg.Algorithm)
+		}
+		key := &SigningKey{
+			ID:        hmacKeyID,
+			Method:    jwt.SigningMethodHS256,
+			signKey:   []byte(cfg.Secret),
+			verifyKey: []byte(cfg.Secret),
+		}
+		return &KeySet{active: key, keys: map[string]*SigningKey{key.ID: key}}, nil
+	}
+
+	if cfg.Algorithm != jwt.SigningMethodRS256.Alg() && cfg.Algorithm != jwt.SigningMethodEdDSA.Alg() {
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", cfg.Algorithm)
+	}
+	if cfg.KeysDir == "" || cfg.ActiveKeyID == "" {
+		return nil, fmt.Errorf("JWT keys directory and active key ID are required for %s", cfg.Algorithm)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list JWT keys: %w", err)
+	}
+
+	keySet := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := loadPEMKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		keySet.keys[kid] = key
+	}
+
+	active, ok := keySet.keys[cfg.ActiveKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active JWT key %q not found in %s", cfg.ActiveKeyID, cfg.KeysDir)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active JWT key %q has no private key", cfg.ActiveKeyID)
+	}
+	if active.Method.Alg() != cfg.Algorithm {
+		return nil, fmt.Errorf("active JWT key %q is %s, expected %s", cfg.ActiveKeyID, active.Method.Alg(), cfg.Algorithm)
+	}
+	keySet.active = active
+
+	return keySet, nil
+}
+
+// loadPEMKey parses an RSA or Ed25519 key, private or public, from a PEM file
+func loadPEMKey(kid, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key %q: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %q is not PEM encoded", kid)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("JWT key %q has unsupported PEM type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT key %q: %w", kid, err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, signKey: key, verifyKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: key, verifyKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("JWT key %q has unsupported key type %T", kid, parsed)
+	}
+}
+
+// Active returns the key new tokens are signed with
+func (k *KeySet) Active() *SigningKey {
+	return k.active
+}
+
+// Lookup returns the verification key with the given ID
+func (k *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// JWKS returns the public halves of all asymmetric keys. HMAC secrets are never published.
+func (k *KeySet) JWKS() JWKSet {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: kid,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
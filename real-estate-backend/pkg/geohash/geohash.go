@@ -0,0 +1,54 @@
+package geohash
+
+// base32 is the geohash alphabet (no a, i, l or o)
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxPrecision is the longest geohash Encode produces, about 3.7 cm by 1.9 cm
+const MaxPrecision = 12
+
+// Encode returns the geohash of the coordinate with the given number of
+// characters. Each character narrows the cell; neighbouring points share a
+// prefix, so truncating a geohash gives the enclosing cell.
+func Encode(lat, lng float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+	hash := make([]byte, 0, precision)
+	even := true // Bits alternate between longitude and latitude, longitude first
+	var bit, ch int
+
+	for len(hash) < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				minLng = mid
+			} else {
+				ch <<= 1
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			hash = append(hash, base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
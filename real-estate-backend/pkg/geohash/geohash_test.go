@@ -0,0 +1,41 @@
+package geohash
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, lng  float64
+		precision int
+		want      string
+	}{
+		{"reference point", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{"truncated to the enclosing cell", 57.64911, 10.40744, 5, "u4pru"},
+		{"wikipedia example", 42.605, -5.603, 5, "ezs42"},
+		{"origin", 0, 0, 5, "s0000"},
+		{"south west corner", -90, -180, 4, "0000"},
+		{"north east corner", 90, 180, 4, "zzzz"},
+		{"precision below one is one", 57.64911, 10.40744, 0, "u"},
+		{"precision above the maximum is capped", 57.64911, 10.40744, 20, "u4pruydqqvj8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Encode(tt.lat, tt.lng, tt.precision); got != tt.want {
+				t.Errorf("Encode(%v, %v, %d) = %q, want %q", tt.lat, tt.lng, tt.precision, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePrefix(t *testing.T) {
+	full := Encode(57.64911, 10.40744, MaxPrecision)
+	if len(full) != MaxPrecision {
+		t.Fatalf("len(Encode(..., MaxPrecision)) = %d, want %d", len(full), MaxPrecision)
+	}
+	for precision := 1; precision < MaxPrecision; precision++ {
+		if got := Encode(57.64911, 10.40744, precision); got != full[:precision] {
+			t.Errorf("Encode(..., %d) = %q, want prefix %q", precision, got, full[:precision])
+		}
+	}
+}